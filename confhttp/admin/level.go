@@ -0,0 +1,226 @@
+// Package admin provides handlers to administrate a running server, which
+// are usually mounted on a separate group of the kernel protected by some
+// authentication middleware, e.g.:
+//
+//	admin.NewLevelController(l).Register(s.Kernel().Group("/admin", auth))
+package admin
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rbee3u/gohelp/confhttp/status"
+	"github.com/rbee3u/gohelp/conflog"
+	"github.com/sirupsen/logrus"
+)
+
+// LevelController gets and sets the levels of a logger at runtime, a level
+// set with ttl is reverted automatically once the ttl expires, so that a
+// forgotten debug level does not persist.
+type LevelController struct {
+	l *conflog.Logger
+
+	mu      sync.Mutex
+	pending map[string]*revert
+}
+
+type revert struct {
+	timer   *time.Timer
+	level   logrus.Level
+	present bool
+}
+
+// LevelRequest is the body of PUT, Level is required and TTL is a duration
+// like "10m", the level is permanent if TTL is empty.
+type LevelRequest struct {
+	Level string `json:"level"`
+	TTL   string `json:"ttl"`
+}
+
+// LevelResponse is the body of responses, Name is empty for the global
+// level, and Levels is all the levels in the form of conflog.Logger.Level.
+type LevelResponse struct {
+	Name   string `json:"name"`
+	Level  string `json:"level"`
+	Levels string `json:"levels"`
+}
+
+func NewLevelController(l *conflog.Logger) *LevelController {
+	return &LevelController{l: l, pending: map[string]*revert{}}
+}
+
+// Register mounts the following routes on r:
+//
+//	GET    /level        get the global level
+//	PUT    /level        set the global level
+//	GET    /level/:name  get the effective level of name
+//	PUT    /level/:name  override the level of name
+//	DELETE /level/:name  remove the overridden level of name
+func (lc *LevelController) Register(r gin.IRouter) {
+	r.GET("/level", lc.get)
+	r.PUT("/level", lc.put)
+	r.GET("/level/:name", lc.get)
+	r.PUT("/level/:name", lc.put)
+	r.DELETE("/level/:name", lc.delete)
+}
+
+func (lc *LevelController) get(c *gin.Context) {
+	c.JSON(http.StatusOK, lc.response(c.Param("name")))
+}
+
+func (lc *LevelController) put(c *gin.Context) {
+	var req LevelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abortWithError(c, status.Wrap(err, http.StatusBadRequest, "invalid body"))
+
+		return
+	}
+
+	level, err := logrus.ParseLevel(req.Level)
+	if err != nil {
+		abortWithError(c, status.Wrap(err, http.StatusBadRequest, "invalid level"))
+
+		return
+	}
+
+	var ttl time.Duration
+	if len(req.TTL) != 0 {
+		if ttl, err = time.ParseDuration(req.TTL); err != nil || ttl <= 0 {
+			abortWithError(c, status.Errorf(http.StatusBadRequest, "invalid ttl: %q", req.TTL))
+
+			return
+		}
+	}
+
+	name := c.Param("name")
+	if !validName(name) {
+		abortWithError(c, status.Errorf(http.StatusBadRequest, "invalid name: %q", name))
+
+		return
+	}
+
+	lc.mu.Lock()
+	err = lc.schedule(name, ttl)
+	if err == nil {
+		lc.l.SetNamedLevel(name, level)
+	}
+	lc.mu.Unlock()
+
+	if err != nil {
+		abortWithError(c, status.Wrap(err, http.StatusInternalServerError, "failed to schedule"))
+
+		return
+	}
+
+	c.JSON(http.StatusOK, lc.response(name))
+}
+
+func (lc *LevelController) delete(c *gin.Context) {
+	name := c.Param("name")
+	if !validName(name) {
+		abortWithError(c, status.Errorf(http.StatusBadRequest, "invalid name: %q", name))
+
+		return
+	}
+
+	lc.mu.Lock()
+	err := lc.schedule(name, 0)
+	if err == nil {
+		lc.l.UnsetNamedLevel(name)
+	}
+	lc.mu.Unlock()
+
+	if err != nil {
+		abortWithError(c, status.Wrap(err, http.StatusInternalServerError, "failed to schedule"))
+
+		return
+	}
+
+	c.JSON(http.StatusOK, lc.response(name))
+}
+
+// validName reports whether name can be written in the form of
+// conflog.Logger.Level, which separates the names by "," and "=".
+func validName(name string) bool {
+	return !strings.ContainsAny(name, ",= \t\n")
+}
+
+// schedule arranges the revert of name after ttl, the state to revert to is
+// the one before the first change within a chain of changes with ttl. A zero
+// ttl cancels the pending revert, which makes the next change permanent.
+func (lc *LevelController) schedule(name string, ttl time.Duration) error {
+	var rv *revert
+
+	// A timer may have fired and be waiting for the lock, so the record is
+	// never reused, otherwise the stale timer would revert the new change.
+	if prev, ok := lc.pending[name]; ok {
+		prev.timer.Stop()
+		delete(lc.pending, name)
+
+		rv = &revert{level: prev.level, present: prev.present}
+	} else {
+		current, err := lc.current(name)
+		if err != nil {
+			return err
+		}
+
+		rv = current
+	}
+
+	if ttl <= 0 {
+		return nil
+	}
+
+	rv.timer = time.AfterFunc(ttl, func() {
+		lc.mu.Lock()
+		defer lc.mu.Unlock()
+
+		if lc.pending[name] != rv {
+			return
+		}
+
+		delete(lc.pending, name)
+
+		switch {
+		case len(name) == 0 || rv.present:
+			lc.l.SetNamedLevel(name, rv.level)
+		default:
+			lc.l.UnsetNamedLevel(name)
+		}
+	})
+	lc.pending[name] = rv
+
+	return nil
+}
+
+// current captures the level of name, and whether it is overridden.
+func (lc *LevelController) current(name string) (*revert, error) {
+	if len(name) == 0 {
+		return &revert{level: lc.l.NamedLevel(""), present: true}, nil
+	}
+
+	_, overrides, err := conflog.ParseLevels(lc.l.Levels())
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse levels: %w", err)
+	}
+
+	level, present := overrides[name]
+
+	return &revert{level: level, present: present}, nil
+}
+
+func (lc *LevelController) response(name string) *LevelResponse {
+	return &LevelResponse{
+		Name:   name,
+		Level:  lc.l.NamedLevel(name).String(),
+		Levels: lc.l.Levels(),
+	}
+}
+
+func abortWithError(c *gin.Context, err error) {
+	c.AbortWithStatusJSON(status.GetCode(err), gin.H{"error": err.Error()})
+}
@@ -0,0 +1,88 @@
+package admin_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rbee3u/gohelp/confhttp/admin"
+	"github.com/rbee3u/gohelp/conflog"
+)
+
+func TestLevelController(t *testing.T) {
+	l, err := conflog.New(conflog.WithLevel("info,http=warn"))
+	if err != nil {
+		t.Fatalf("failed to new: %v", err)
+	}
+
+	gin.SetMode(gin.ReleaseMode)
+
+	engine := gin.New()
+	admin.NewLevelController(l).Register(engine.Group("/admin"))
+
+	do := func(method, path, body string) (int, *admin.LevelResponse) {
+		t.Helper()
+
+		rec := httptest.NewRecorder()
+		engine.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+
+		var resp admin.LevelResponse
+		_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+
+		return rec.Code, &resp
+	}
+
+	if code, resp := do(http.MethodGet, "/admin/level/http", ""); code != http.StatusOK || resp.Level != "warning" {
+		t.Errorf("unexpected response: %v %+v", code, resp)
+	}
+
+	code, resp := do(http.MethodPut, "/admin/level/db", `{"level":"debug","ttl":"50ms"}`)
+	if code != http.StatusOK || resp.Levels != "info,db=debug,http=warning" {
+		t.Errorf("unexpected response: %v %+v", code, resp)
+	}
+
+	// A second change within the ttl still reverts to the original state.
+	if code, _ := do(http.MethodPut, "/admin/level/db", `{"level":"trace","ttl":"50ms"}`); code != http.StatusOK {
+		t.Errorf("unexpected code: %v", code)
+	}
+
+	if code, _ := do(http.MethodPut, "/admin/level", `{"level":"error"}`); code != http.StatusOK {
+		t.Errorf("unexpected code: %v", code)
+	}
+
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); {
+		if l.Levels() == "error,http=warning" {
+			break
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if got, want := l.Levels(), "error,http=warning"; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+
+	if code, resp := do(http.MethodDelete, "/admin/level/http", ""); code != http.StatusOK || resp.Level != "error" {
+		t.Errorf("unexpected response: %v %+v", code, resp)
+	}
+
+	for _, body := range []string{`{"level":"loud"}`, `{"level":"info","ttl":"x"}`, `{`} {
+		if code, _ := do(http.MethodPut, "/admin/level", body); code != http.StatusBadRequest {
+			t.Errorf("body: %s, got: %v, want: %v", body, code, http.StatusBadRequest)
+		}
+	}
+
+	for _, path := range []string{"/admin/level/a,b", "/admin/level/a=b"} {
+		if code, _ := do(http.MethodPut, path, `{"level":"info"}`); code != http.StatusBadRequest {
+			t.Errorf("path: %s, got: %v, want: %v", path, code, http.StatusBadRequest)
+		}
+	}
+
+	if got, want := l.Levels(), "error"; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+}
@@ -0,0 +1,300 @@
+// Package metrics records the metrics of http requests, and exposes them in
+// the Prometheus text exposition format without depending on the client of
+// Prometheus, e.g.:
+//
+//	m := metrics.New()
+//	s.Kernel().Use(m.Middleware())
+//	m.Register(s.Kernel())
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rbee3u/gohelp/confhttp"
+)
+
+const (
+	// ContentType is the content type of the text exposition format.
+	ContentType = "text/plain; version=0.0.4; charset=utf-8"
+	// UnmatchedRoute is the route label of requests which match no route,
+	// so that unknown paths do not blow up the cardinality.
+	UnmatchedRoute = "unmatched"
+	// OtherMethod is the method label of requests whose method is not one
+	// of the standard methods, for the same reason.
+	OtherMethod = "other"
+)
+
+// Metrics keeps the counters of requests, the histograms of latencies and
+// the gauges of in-flight requests, labeled by route template, method and
+// status, except that the in-flight requests have no status yet.
+type Metrics struct {
+	namespace string
+	buckets   []float64
+	server    *confhttp.Server
+
+	mu        sync.Mutex
+	requests  map[labels]uint64
+	latencies map[labels]*histogram
+	inFlight  map[labels]int64
+}
+
+type labels struct {
+	method string
+	route  string
+	status string
+}
+
+type histogram struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+type Option func(*Metrics)
+
+// WithNamespace prefixes the names of metrics with namespace and "_".
+func WithNamespace(namespace string) Option {
+	return func(m *Metrics) {
+		m.namespace = namespace
+	}
+}
+
+// WithBuckets replaces the upper bounds in seconds of the latency buckets,
+// which are the default buckets of Prometheus.
+func WithBuckets(buckets ...float64) Option {
+	return func(m *Metrics) {
+		m.buckets = buckets
+	}
+}
+
+// WithServer exposes the readiness and the open connections of s, and the
+// stats of its last graceful shutdown, see confhttp.DrainStats.
+func WithServer(s *confhttp.Server) Option {
+	return func(m *Metrics) {
+		m.server = s
+	}
+}
+
+func New(opts ...Option) *Metrics {
+	m := &Metrics{
+		buckets:   []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+		requests:  map[labels]uint64{},
+		latencies: map[labels]*histogram{},
+		inFlight:  map[labels]int64{},
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	m.buckets = append([]float64(nil), m.buckets...)
+	sort.Float64s(m.buckets)
+
+	return m
+}
+
+// Middleware records the metrics of each request.
+func (m *Metrics) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if len(route) == 0 {
+			route = UnmatchedRoute
+		}
+
+		flight := labels{method: methodLabel(c.Request.Method), route: route}
+		m.addInFlight(flight, 1)
+
+		start := time.Now()
+
+		defer func() {
+			m.addInFlight(flight, -1)
+			m.observe(labels{method: flight.method, route: route, status: strconv.Itoa(c.Writer.Status())}, time.Since(start))
+		}()
+
+		c.Next()
+	}
+}
+
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	}
+
+	return OtherMethod
+}
+
+func (m *Metrics) addInFlight(l labels, delta int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.inFlight[l] += delta
+}
+
+func (m *Metrics) observe(l labels, latency time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.requests[l]++
+
+	h, ok := m.latencies[l]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(m.buckets))}
+		m.latencies[l] = h
+	}
+
+	seconds := latency.Seconds()
+	for i, bound := range m.buckets {
+		if seconds <= bound {
+			h.counts[i]++
+		}
+	}
+
+	h.sum += seconds
+	h.count++
+}
+
+// Register mounts GET /metrics on r.
+func (m *Metrics) Register(r gin.IRouter) {
+	r.GET("/metrics", gin.WrapH(m.Handler()))
+}
+
+// Handler serves the metrics in the text exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+
+		bw := bufio.NewWriter(w)
+		m.write(bw)
+		_ = bw.Flush()
+	})
+}
+
+func (m *Metrics) name(name string) string {
+	if len(m.namespace) == 0 {
+		return name
+	}
+
+	return m.namespace + "_" + name
+}
+
+func (m *Metrics) write(w *bufio.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	name := m.name("http_requests_total")
+	writeHeader(w, name, "counter", "Total number of http requests.")
+
+	for _, l := range sortedLabels(m.requests) {
+		_, _ = fmt.Fprintf(w, "%s%s %d\n", name, l.format(""), m.requests[l])
+	}
+
+	name = m.name("http_request_duration_seconds")
+	writeHeader(w, name, "histogram", "Latency of http requests in seconds.")
+
+	for _, l := range sortedLabels(m.latencies) {
+		h := m.latencies[l]
+
+		for i, bound := range m.buckets {
+			_, _ = fmt.Fprintf(w, "%s_bucket%s %d\n", name, l.format(formatFloat(bound)), h.counts[i])
+		}
+
+		_, _ = fmt.Fprintf(w, "%s_bucket%s %d\n", name, l.format("+Inf"), h.count)
+		_, _ = fmt.Fprintf(w, "%s_sum%s %s\n", name, l.format(""), formatFloat(h.sum))
+		_, _ = fmt.Fprintf(w, "%s_count%s %d\n", name, l.format(""), h.count)
+	}
+
+	name = m.name("http_requests_in_flight")
+	writeHeader(w, name, "gauge", "Number of http requests being served.")
+
+	for _, l := range sortedLabels(m.inFlight) {
+		_, _ = fmt.Fprintf(w, "%s%s %d\n", name, l.format(""), m.inFlight[l])
+	}
+
+	if m.server != nil {
+		m.writeServer(w)
+	}
+}
+
+func (m *Metrics) writeServer(w *bufio.Writer) {
+	ready := 0
+	if m.server.Ready() {
+		ready = 1
+	}
+
+	drain := m.server.LastDrain()
+
+	for _, g := range []struct {
+		name  string
+		help  string
+		value string
+	}{
+		{"http_server_ready", "Whether the server is ready to serve.", strconv.Itoa(ready)},
+		{"http_server_connections", "Number of open connections.", strconv.FormatInt(m.server.Connections(), 10)},
+		{"http_server_last_drain_connections", "Number of connections open when the last drain began.", strconv.FormatInt(drain.Connections, 10)},
+		{"http_server_last_drain_forced", "Number of connections closed forcibly by the last drain.", strconv.FormatInt(drain.Forced, 10)},
+		{"http_server_last_drain_duration_seconds", "Duration of the last drain in seconds.", formatFloat(drain.Duration.Seconds())},
+	} {
+		name := m.name(g.name)
+		writeHeader(w, name, "gauge", g.help)
+		_, _ = fmt.Fprintf(w, "%s %s\n", name, g.value)
+	}
+}
+
+func writeHeader(w *bufio.Writer, name string, kind string, help string) {
+	_, _ = fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func sortedLabels[V any](series map[labels]V) []labels {
+	keys := make([]labels, 0, len(series))
+	for l := range series {
+		keys = append(keys, l)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].route != keys[j].route {
+			return keys[i].route < keys[j].route
+		}
+
+		if keys[i].method != keys[j].method {
+			return keys[i].method < keys[j].method
+		}
+
+		return keys[i].status < keys[j].status
+	})
+
+	return keys
+}
+
+// format formats the labels, the status is omitted if it is empty, and le
+// is appended if it is not empty.
+func (l labels) format(le string) string {
+	pairs := []string{`method="` + escape(l.method) + `"`, `route="` + escape(l.route) + `"`}
+
+	if len(l.status) != 0 {
+		pairs = append(pairs, `status="`+l.status+`"`)
+	}
+
+	if len(le) != 0 {
+		pairs = append(pairs, `le="`+le+`"`)
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func escape(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
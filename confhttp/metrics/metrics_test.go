@@ -0,0 +1,88 @@
+package metrics_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rbee3u/gohelp/confhttp"
+	"github.com/rbee3u/gohelp/confhttp/metrics"
+)
+
+func TestMetrics(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+
+	m := metrics.New(metrics.WithNamespace("app"), metrics.WithBuckets(0.1, 1))
+
+	var inFlight string
+
+	engine := gin.New()
+	engine.Use(m.Middleware())
+	m.Register(engine)
+	engine.GET("/users/:id", func(c *gin.Context) {
+		rec := httptest.NewRecorder()
+		m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		inFlight = rec.Body.String()
+		c.Status(http.StatusOK)
+	})
+
+	for _, path := range []string{"/users/1", "/users/2", "/missing"} {
+		engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("FOO", "/missing", nil))
+
+	if want := `app_http_requests_in_flight{method="GET",route="/users/:id"} 1`; !strings.Contains(inFlight, want) {
+		t.Errorf("got: %q, want: %q", inFlight, want)
+	}
+
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if got, want := rec.Header().Get("Content-Type"), metrics.ContentType; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+
+	for _, want := range []string{
+		"# TYPE app_http_requests_total counter",
+		`app_http_requests_total{method="GET",route="/users/:id",status="200"} 2`,
+		`app_http_requests_total{method="GET",route="unmatched",status="404"} 1`,
+		`app_http_requests_total{method="other",route="unmatched",status="404"} 1`,
+		"# TYPE app_http_request_duration_seconds histogram",
+		`app_http_request_duration_seconds_bucket{method="GET",route="/users/:id",status="200",le="0.1"} 2`,
+		`app_http_request_duration_seconds_bucket{method="GET",route="/users/:id",status="200",le="+Inf"} 2`,
+		`app_http_request_duration_seconds_count{method="GET",route="/users/:id",status="200"} 2`,
+		`app_http_requests_in_flight{method="GET",route="/users/:id"} 0`,
+		`app_http_requests_in_flight{method="GET",route="/metrics"} 1`,
+	} {
+		if got := rec.Body.String(); !strings.Contains(got, want) {
+			t.Errorf("got: %q, want: %q", got, want)
+		}
+	}
+}
+
+func TestMetricsWithServer(t *testing.T) {
+	s, err := confhttp.New()
+	if err != nil {
+		t.Fatalf("failed to new: %v", err)
+	}
+
+	m := metrics.New(metrics.WithServer(s))
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	for _, want := range []string{
+		"# TYPE http_server_ready gauge",
+		"http_server_ready 0\n",
+		"http_server_connections 0\n",
+		"http_server_last_drain_forced 0\n",
+		"http_server_last_drain_duration_seconds 0\n",
+	} {
+		if got := rec.Body.String(); !strings.Contains(got, want) {
+			t.Errorf("got: %q, want: %q", got, want)
+		}
+	}
+}
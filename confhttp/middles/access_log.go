@@ -0,0 +1,188 @@
+package middles
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rbee3u/gohelp/conflog"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// AccessLogJSON logs the access as fields, which are rendered as JSON
+	// by the "json" format of the logger.
+	AccessLogJSON = "json"
+	// AccessLogCombined logs the access as the message in the Apache combined
+	// log format.
+	AccessLogCombined = "combined"
+
+	combinedTimeLayout = "02/Jan/2006:15:04:05 -0700"
+)
+
+type accessLog struct {
+	format    string
+	slow      time.Duration
+	slowLevel logrus.Level
+	redactor  *Redactor
+	skipPaths map[string]bool
+}
+
+type AccessLogOption func(*accessLog)
+
+// WithAccessLogFormat sets the format, which is one of AccessLogJSON and
+// AccessLogCombined.
+func WithAccessLogFormat(format string) AccessLogOption {
+	return func(al *accessLog) {
+		al.format = format
+	}
+}
+
+// WithSlowThreshold escalates the level of requests slower than slow to the
+// slow level, zero disables escalation.
+func WithSlowThreshold(slow time.Duration) AccessLogOption {
+	return func(al *accessLog) {
+		al.slow = slow
+	}
+}
+
+// WithSlowLevel sets the level of slow requests, it is warn by default.
+func WithSlowLevel(level logrus.Level) AccessLogOption {
+	return func(al *accessLog) {
+		al.slowLevel = level
+	}
+}
+
+// WithAccessLogRedactor replaces the DefaultRedactor applied to the uri,
+// nil disables redaction.
+func WithAccessLogRedactor(redactor *Redactor) AccessLogOption {
+	return func(al *accessLog) {
+		al.redactor = redactor
+	}
+}
+
+// WithAccessLogSkipPaths skips the requests whose route or path is one of
+// paths, e.g. "/healthz".
+func WithAccessLogSkipPaths(paths ...string) AccessLogOption {
+	return func(al *accessLog) {
+		for _, path := range paths {
+			al.skipPaths[path] = true
+		}
+	}
+}
+
+// AccessLog logs each request as a single entry when it completes, with the
+// method, route template, status, latency, bytes in and out, client ip,
+// user agent, and the request id if RequestID is also used. The level is
+// info, error for 5xx statuses, and the slow level for slow requests if it
+// is more severe.
+func AccessLog(l *conflog.Logger, opts ...AccessLogOption) gin.HandlerFunc {
+	al := &accessLog{
+		format:    AccessLogJSON,
+		slowLevel: logrus.WarnLevel,
+		redactor:  DefaultRedactor(),
+		skipPaths: map[string]bool{},
+	}
+
+	for _, opt := range opts {
+		opt(al)
+	}
+
+	if al.redactor == nil {
+		al.redactor = &Redactor{}
+	}
+
+	return func(c *gin.Context) {
+		if al.skipPaths[c.FullPath()] || al.skipPaths[c.Request.URL.Path] {
+			return
+		}
+
+		start := time.Now()
+
+		var body *countingReader
+		if c.Request.Body != nil && c.Request.Body != http.NoBody {
+			body = &countingReader{ReadCloser: c.Request.Body}
+			c.Request.Body = body
+		}
+
+		c.Next()
+
+		latency := time.Since(start)
+		status := c.Writer.Status()
+
+		bytesIn := c.Request.ContentLength
+		if body != nil && bytesIn < 0 {
+			bytesIn = body.n
+		}
+
+		bytesOut := c.Writer.Size()
+		if bytesOut < 0 {
+			bytesOut = 0
+		}
+
+		level := logrus.InfoLevel
+		if status >= http.StatusInternalServerError {
+			level = logrus.ErrorLevel
+		}
+
+		if al.slow > 0 && latency > al.slow && al.slowLevel < level {
+			level = al.slowLevel
+		}
+
+		uri := al.redactor.URI(c.Request.URL)
+		entry := l.WithContext(c.Request.Context())
+
+		if al.format == AccessLogCombined {
+			entry.Log(level, combinedLine(c, start, uri, status, bytesOut))
+
+			return
+		}
+
+		entry = entry.WithField("method", c.Request.Method)
+		entry = entry.WithField("route", c.FullPath())
+		entry = entry.WithField("uri", uri)
+		entry = entry.WithField("status", status)
+		entry = entry.WithField("latency_ms", float64(latency.Microseconds())/1000)
+		entry = entry.WithField("bytes_in", bytesIn)
+		entry = entry.WithField("bytes_out", bytesOut)
+		entry = entry.WithField("client_ip", c.ClientIP())
+		entry = entry.WithField("user_agent", c.Request.UserAgent())
+		entry.Log(level, "http access")
+	}
+}
+
+func combinedLine(c *gin.Context, start time.Time, uri string, status int, bytesOut int) string {
+	size := "-"
+	if bytesOut > 0 {
+		size = strconv.Itoa(bytesOut)
+	}
+
+	return fmt.Sprintf("%s - %s [%s] %q %d %s %q %q",
+		c.ClientIP(), dashIfEmpty(c.GetString(gin.AuthUserKey)), start.Format(combinedTimeLayout),
+		c.Request.Method+" "+uri+" "+c.Request.Proto, status, size,
+		dashIfEmpty(c.Request.Referer()), dashIfEmpty(c.Request.UserAgent()))
+}
+
+func dashIfEmpty(s string) string {
+	if len(s) == 0 {
+		return "-"
+	}
+
+	return s
+}
+
+// countingReader counts the bytes read from the request body.
+type countingReader struct {
+	io.ReadCloser
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.n += int64(n)
+
+	return n, err //nolint:wrapcheck // io.EOF must not be wrapped.
+}
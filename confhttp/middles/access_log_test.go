@@ -0,0 +1,91 @@
+package middles_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rbee3u/gohelp/confhttp/middles"
+	"github.com/rbee3u/gohelp/conflog/conflogtest"
+	"github.com/sirupsen/logrus"
+)
+
+func TestAccessLog(t *testing.T) {
+	l, r := conflogtest.New(t)
+
+	gin.SetMode(gin.ReleaseMode)
+
+	engine := gin.New()
+	engine.Use(middles.AccessLog(l), middles.RequestID(l))
+	engine.POST("/users/:id", func(c *gin.Context) {
+		c.String(http.StatusCreated, "created")
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/users/7?token=abc", strings.NewReader("hello"))
+	req.Header.Set("User-Agent", "test/1.0")
+	req.Header.Set(middles.RequestIDHeader, "abc")
+
+	engine.ServeHTTP(httptest.NewRecorder(), req)
+
+	entries := r.Entries()
+	if got, want := len(entries), 1; got != want {
+		t.Fatalf("got: %v, want: %v", got, want)
+	}
+
+	r.AssertCount(t, logrus.InfoLevel, 1)
+	r.AssertField(t, "route", "/users/:id")
+	r.AssertField(t, "uri", "/users/7?token=abc")
+	r.AssertField(t, "status", http.StatusCreated)
+	r.AssertField(t, "bytes_in", int64(5))
+	r.AssertField(t, "bytes_out", 7)
+	r.AssertField(t, "user_agent", "test/1.0")
+	r.AssertField(t, middles.RequestIDField, "abc")
+
+	if _, ok := entries[0].Fields["latency_ms"].(float64); !ok {
+		t.Errorf("got: %T, want: float64", entries[0].Fields["latency_ms"])
+	}
+}
+
+func TestAccessLogCombined(t *testing.T) {
+	l, r := conflogtest.New(t)
+
+	gin.SetMode(gin.ReleaseMode)
+
+	engine := gin.New()
+	engine.Use(middles.AccessLog(l, middles.WithAccessLogFormat(middles.AccessLogCombined)))
+	engine.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("User-Agent", "test/1.0")
+
+	engine.ServeHTTP(httptest.NewRecorder(), req)
+
+	r.AssertContains(t, `192.0.2.1 - - [`)
+	r.AssertContains(t, `] "GET / HTTP/1.1" 200 2 "-" "test/1.0"`)
+}
+
+func TestAccessLogEscalation(t *testing.T) {
+	l, r := conflogtest.New(t)
+
+	gin.SetMode(gin.ReleaseMode)
+
+	engine := gin.New()
+	engine.Use(middles.AccessLog(l, middles.WithSlowThreshold(time.Millisecond)))
+	engine.GET("/slow", func(c *gin.Context) {
+		time.Sleep(2 * time.Millisecond)
+	})
+	engine.GET("/broken", func(c *gin.Context) {
+		c.Status(http.StatusInternalServerError)
+	})
+
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/slow", nil))
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/broken", nil))
+
+	r.AssertCount(t, logrus.WarnLevel, 1)
+	r.AssertCount(t, logrus.ErrorLevel, 1)
+}
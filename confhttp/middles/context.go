@@ -0,0 +1,21 @@
+package middles
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/rbee3u/gohelp/conflog"
+)
+
+// ContextLogger seeds the context of each request with an entry of l which
+// carries the request metadata, handlers can retrieve it through
+// conflog.FromContext or l.WithContext.
+func ContextLogger(l *conflog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		entry := l.WithContext(ctx)
+		entry = entry.WithField("method", c.Request.Method)
+		entry = entry.WithField("route", c.FullPath())
+		entry = entry.WithField("client_ip", c.ClientIP())
+		c.Request = c.Request.WithContext(conflog.IntoContext(ctx, entry))
+		c.Next()
+	}
+}
@@ -0,0 +1,35 @@
+package middles_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rbee3u/gohelp/confhttp/middles"
+	"github.com/rbee3u/gohelp/conflog"
+	"github.com/rbee3u/gohelp/conflog/conflogtest"
+)
+
+func TestContextLogger(t *testing.T) {
+	l, r := conflogtest.New(t)
+
+	gin.SetMode(gin.ReleaseMode)
+
+	engine := gin.New()
+	engine.Use(middles.ContextLogger(l))
+	engine.GET("/users/:id", func(c *gin.Context) {
+		conflog.FromContext(c.Request.Context()).Info("handling")
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+
+	engine.ServeHTTP(httptest.NewRecorder(), req)
+
+	r.AssertContains(t, "handling")
+	r.AssertField(t, "method", http.MethodGet)
+	r.AssertField(t, "route", "/users/:id")
+	r.AssertField(t, "client_ip", "192.0.2.1")
+}
@@ -0,0 +1,87 @@
+package middles
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BodyLimit limits the request body to n bytes, a request whose declared
+// length exceeds n is rejected with 413, otherwise reading beyond n fails
+// with *http.MaxBytesError, and a 400 responded after that is turned into
+// 413. It is usually placed after the logging and recovery middlewares, so
+// that the rejected requests are logged too.
+func BodyLimit(n int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.ContentLength > n {
+			c.AbortWithStatus(http.StatusRequestEntityTooLarge)
+
+			return
+		}
+
+		if c.Request.Body != nil && c.Request.Body != http.NoBody {
+			body := &limitedBody{ReadCloser: http.MaxBytesReader(c.Writer, c.Request.Body, n)}
+			c.Request.Body = body
+			c.Writer = &limitedWriter{ResponseWriter: c.Writer, body: body}
+		}
+
+		c.Next()
+	}
+}
+
+// limitedBody records whether the limit has been exceeded.
+type limitedBody struct {
+	io.ReadCloser
+	exceeded bool
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+
+	var mbe *http.MaxBytesError
+	if errors.As(err, &mbe) {
+		b.exceeded = true
+	}
+
+	return n, err //nolint:wrapcheck // io.EOF must not be wrapped.
+}
+
+// limitedWriter turns 400 into 413 once the limit has been exceeded, since
+// handlers usually respond 400 to any error of reading the body.
+type limitedWriter struct {
+	gin.ResponseWriter
+	body *limitedBody
+}
+
+func (w *limitedWriter) WriteHeader(code int) {
+	if code == http.StatusBadRequest && w.body.exceeded {
+		code = http.StatusRequestEntityTooLarge
+	}
+
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Deadline sets an advisory deadline of d on the request context, which is
+// usually used per route, e.g. engine.GET("/report",
+// middles.Deadline(time.Minute), handler). It does not cut off a handler
+// which ignores the context, unlike http.TimeoutHandler, and 503 is only
+// responded if the deadline is exceeded before anything is written. Note
+// that a non-zero WriteTimeout of the server shorter than d cuts the
+// response first.
+func Deadline(d time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), d)
+		defer cancel()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) && !c.Writer.Written() {
+			c.AbortWithStatus(http.StatusServiceUnavailable)
+		}
+	}
+}
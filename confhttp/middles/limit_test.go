@@ -0,0 +1,76 @@
+package middles_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rbee3u/gohelp/confhttp/middles"
+)
+
+func TestBodyLimit(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+
+	engine := gin.New()
+	engine.Use(middles.BodyLimit(4))
+	engine.POST("/", func(c *gin.Context) {
+		if _, err := io.ReadAll(c.Request.Body); err != nil {
+			c.String(http.StatusBadRequest, err.Error())
+
+			return
+		}
+
+		c.Status(http.StatusOK)
+	})
+
+	for _, tc := range []struct {
+		body    string
+		chunked bool
+		want    int
+	}{
+		{body: "abc", want: http.StatusOK},
+		{body: "abcdefgh", want: http.StatusRequestEntityTooLarge},
+		{body: "abcdefgh", chunked: true, want: http.StatusRequestEntityTooLarge},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tc.body))
+		if tc.chunked {
+			req.ContentLength = -1
+		}
+
+		rec := httptest.NewRecorder()
+		engine.ServeHTTP(rec, req)
+
+		if got := rec.Code; got != tc.want {
+			t.Errorf("got: %v, want: %v", got, tc.want)
+		}
+	}
+}
+
+func TestDeadline(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+
+	engine := gin.New()
+	engine.GET("/slow", middles.Deadline(10*time.Millisecond), func(c *gin.Context) {
+		select {
+		case <-c.Request.Context().Done():
+		case <-time.After(time.Second):
+			c.Status(http.StatusOK)
+		}
+	})
+	engine.GET("/fast", middles.Deadline(time.Second), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	for path, want := range map[string]int{"/slow": http.StatusServiceUnavailable, "/fast": http.StatusOK} {
+		rec := httptest.NewRecorder()
+		engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+		if got := rec.Code; got != want {
+			t.Errorf("got: %v, want: %v", got, want)
+		}
+	}
+}
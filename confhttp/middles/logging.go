@@ -2,38 +2,200 @@ package middles
 
 import (
 	"bytes"
+	"encoding/base64"
 	"fmt"
-	"io/ioutil"
+	"io"
+	"mime"
 	"net/http"
 	"sort"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/gin-gonic/gin"
 	"github.com/rbee3u/gohelp/conflog"
 )
 
-func LoggingRequest(l *conflog.Logger) gin.HandlerFunc {
+const (
+	// DefaultMaxBody is the default number of captured bytes of bodies.
+	DefaultMaxBody = 8192
+	// BinaryBodySkip logs only the size of non-text bodies.
+	BinaryBodySkip = "skip"
+	// BinaryBodyBase64 logs the captured bytes of non-text bodies in base64.
+	BinaryBodyBase64 = "base64"
+)
+
+type logging struct {
+	redactor   *Redactor
+	maxBody    int
+	binaryBody string
+	skipPaths  map[string]bool
+}
+
+type LoggingOption func(*logging)
+
+// WithRedactor replaces the DefaultRedactor, nil disables redaction.
+func WithRedactor(redactor *Redactor) LoggingOption {
+	return func(lg *logging) {
+		lg.redactor = redactor
+	}
+}
+
+// WithMaxBody limits the captured bytes of bodies to maxBody, the rest is
+// streamed without being captured, and a truncation marker is logged. The
+// captured bytes are redacted as a whole, so a truncated JSON or form body,
+// which can not be parsed, is logged as UnparseableBody.
+func WithMaxBody(maxBody int) LoggingOption {
+	return func(lg *logging) {
+		lg.maxBody = maxBody
+	}
+}
+
+// WithBinaryBody decides how to log non-text bodies, it is one of
+// BinaryBodySkip and BinaryBodyBase64.
+func WithBinaryBody(binaryBody string) LoggingOption {
+	return func(lg *logging) {
+		lg.binaryBody = binaryBody
+	}
+}
+
+// WithSkipPaths skips logging of requests whose route or path is one of
+// paths, e.g. "/healthz".
+func WithSkipPaths(paths ...string) LoggingOption {
+	return func(lg *logging) {
+		for _, path := range paths {
+			lg.skipPaths[path] = true
+		}
+	}
+}
+
+func newLogging(opts []LoggingOption) *logging {
+	lg := &logging{
+		redactor:   DefaultRedactor(),
+		maxBody:    DefaultMaxBody,
+		binaryBody: BinaryBodySkip,
+		skipPaths:  map[string]bool{},
+	}
+
+	for _, opt := range opts {
+		opt(lg)
+	}
+
+	if lg.redactor == nil {
+		lg.redactor = &Redactor{}
+	}
+
+	return lg
+}
+
+func (lg *logging) skip(c *gin.Context) bool {
+	return lg.skipPaths[c.FullPath()] || lg.skipPaths[c.Request.URL.Path]
+}
+
+// body formats the captured bytes of a body of total bytes, total is
+// negative if it is unknown.
+func (lg *logging) body(contentType string, captured []byte, total int64) string {
+	truncated := total > int64(len(captured)) || total < 0
+
+	if !isText(contentType, captured) {
+		if lg.binaryBody != BinaryBodyBase64 {
+			if len(contentType) == 0 {
+				contentType = "unknown type"
+			}
+
+			return fmt.Sprintf("[binary %s, %s]", contentType, sizeOf(len(captured), total, truncated))
+		}
+
+		text := base64.StdEncoding.EncodeToString(captured)
+		if truncated {
+			text += fmt.Sprintf("...[truncated, %s]", sizeOf(len(captured), total, truncated))
+		}
+
+		return text
+	}
+
+	if !truncated {
+		return lg.redactor.Body(contentType, captured)
+	}
+
+	// A text may be cut in the middle of a rune.
+	for len(captured) != 0 && !utf8.Valid(captured) {
+		captured = captured[:len(captured)-1]
+	}
+
+	return lg.redactor.Body(contentType, captured) + fmt.Sprintf("...[truncated, %s]", sizeOf(len(captured), total, truncated))
+}
+
+func sizeOf(captured int, total int64, truncated bool) string {
+	switch {
+	case !truncated:
+		return fmt.Sprintf("%d bytes", captured)
+	case total < 0:
+		return fmt.Sprintf("more than %d bytes", captured)
+	}
+
+	return fmt.Sprintf("%d bytes total", total)
+}
+
+func isText(contentType string, body []byte) bool {
+	if len(contentType) == 0 {
+		return utf8.Valid(body)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	switch {
+	case strings.HasPrefix(mediaType, "text/"),
+		strings.HasSuffix(mediaType, "+json"),
+		strings.HasSuffix(mediaType, "+xml"):
+		return true
+	}
+
+	switch mediaType {
+	case "application/json", "application/xml", "application/javascript",
+		"application/x-www-form-urlencoded", "application/x-ndjson":
+		return true
+	}
+
+	return false
+}
+
+func LoggingRequest(l *conflog.Logger, opts ...LoggingOption) gin.HandlerFunc {
+	lg := newLogging(opts)
+
 	return func(c *gin.Context) {
+		if lg.skip(c) {
+			return
+		}
+
+		r := lg.redactor
 		entry := l.WithContext(c.Request.Context())
 		entry = entry.WithField("method", c.Request.Method)
-		entry = entry.WithField("uri", c.Request.URL.RequestURI())
-		entry = entry.WithField("headers", composeHeaders(c.Request.Header))
-		entry = entry.WithField("body", requestBody(c))
+		entry = entry.WithField("uri", r.URI(c.Request.URL))
+		entry = entry.WithField("headers", composeHeaders(r.Header(c.Request.Header)))
+		entry = entry.WithField("body", lg.requestBody(c))
 		entry.Infof("incoming http request")
 	}
 }
 
-func LoggingResponse(l *conflog.Logger) gin.HandlerFunc {
+func LoggingResponse(l *conflog.Logger, opts ...LoggingOption) gin.HandlerFunc {
+	lg := newLogging(opts)
+
 	return func(c *gin.Context) {
-		rw := &responseWriter{Body: new(bytes.Buffer), ResponseWriter: c.Writer}
+		if lg.skip(c) {
+			return
+		}
+
+		r := lg.redactor
+		rw := &responseWriter{ResponseWriter: c.Writer, Body: new(bytes.Buffer), max: lg.maxBody}
 		c.Writer = rw
 		c.Next()
 		statusCode := c.Writer.Status()
 		statusText := http.StatusText(statusCode)
+		contentType := c.Writer.Header().Get("Content-Type")
 		entry := l.WithContext(c.Request.Context())
 		entry = entry.WithField("status", fmt.Sprintf("%v %s", statusCode, statusText))
-		entry = entry.WithField("headers", composeHeaders(c.Writer.Header()))
-		entry = entry.WithField("body", rw.Body.String())
+		entry = entry.WithField("headers", composeHeaders(r.Header(c.Writer.Header())))
+		entry = entry.WithField("body", lg.body(contentType, rw.Body.Bytes(), rw.total))
 		entry.Infof("outgoing http response")
 	}
 }
@@ -49,30 +211,74 @@ func composeHeaders(headers http.Header) string {
 	return strings.Join(pairs, "; ")
 }
 
-func requestBody(c *gin.Context) string {
+// requestBody captures at most maxBody bytes of the request body, the body
+// is replaced with the captured bytes followed by the rest of the original
+// body, so that it is streamed to the handler.
+func (lg *logging) requestBody(c *gin.Context) string {
 	if c.Request.Body == nil || c.Request.Body == http.NoBody {
 		return ""
 	}
 
-	body, _ := ioutil.ReadAll(c.Request.Body)
-	_ = c.Request.Body.Close()
-	c.Request.Body = ioutil.NopCloser(bytes.NewReader(body))
+	// One more byte is read to tell whether the body is truncated.
+	captured, _ := io.ReadAll(io.LimitReader(c.Request.Body, int64(lg.maxBody)+1))
+
+	c.Request.Body = readCloser{
+		Reader: io.MultiReader(bytes.NewReader(captured), c.Request.Body),
+		Closer: c.Request.Body,
+	}
+
+	total := int64(len(captured))
+	if len(captured) > lg.maxBody {
+		captured, total = captured[:lg.maxBody], c.Request.ContentLength
+	}
 
-	return string(body)
+	return lg.body(c.ContentType(), captured, total)
 }
 
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// responseWriter captures at most max bytes of the response body, and
+// counts the total bytes written.
 type responseWriter struct {
 	gin.ResponseWriter
-	Body *bytes.Buffer
+	Body  *bytes.Buffer
+	max   int
+	total int64
 }
 
-func (w responseWriter) Write(body []byte) (int, error) {
-	w.Body.Write(body)
+func (w *responseWriter) Write(body []byte) (int, error) {
+	w.capture(body)
 
 	n, err := w.ResponseWriter.Write(body)
 	if err != nil {
-		return 0, fmt.Errorf("failed to write: %w", err)
+		return n, fmt.Errorf("failed to write: %w", err)
+	}
+
+	return n, nil
+}
+
+func (w *responseWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+
+	n, err := w.ResponseWriter.WriteString(s)
+	if err != nil {
+		return n, fmt.Errorf("failed to write string: %w", err)
 	}
 
 	return n, nil
 }
+
+func (w *responseWriter) capture(body []byte) {
+	if room := w.max - w.Body.Len(); room > 0 {
+		if len(body) > room {
+			w.Body.Write(body[:room])
+		} else {
+			w.Body.Write(body)
+		}
+	}
+
+	w.total += int64(len(body))
+}
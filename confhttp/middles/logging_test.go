@@ -0,0 +1,207 @@
+package middles_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rbee3u/gohelp/confhttp/middles"
+	"github.com/rbee3u/gohelp/conflog/conflogtest"
+)
+
+func TestLoggingRedaction(t *testing.T) {
+	l, r := conflogtest.New(t)
+
+	redactor := middles.DefaultRedactor()
+	redactor.JSONPaths = append(redactor.JSONPaths, "cards.*.cvv")
+	redactor.FormFields = append(redactor.FormFields, "token")
+	redactor.Patterns = []*regexp.Regexp{regexp.MustCompile(middles.CardNumberPattern)}
+
+	gin.SetMode(gin.ReleaseMode)
+
+	engine := gin.New()
+	engine.Use(middles.LoggingRequest(l, middles.WithRedactor(redactor)), middles.LoggingResponse(l, middles.WithRedactor(redactor)))
+	engine.POST("/", func(c *gin.Context) {
+		c.Header("Set-Cookie", "session=secret")
+		c.String(http.StatusOK, "paid by 4111 1111 1111 1111")
+	})
+
+	body := `{"user":"bob","password":"hunter2","cards":[{"number":"4111111111111111","cvv":"123"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/?token=abc&page=1", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer secret")
+
+	engine.ServeHTTP(httptest.NewRecorder(), req)
+
+	entries := r.Entries()
+	if got, want := len(entries), 2; got != want {
+		t.Fatalf("got: %v, want: %v", got, want)
+	}
+
+	for _, secret := range []string{"hunter2", "123\"", "4111", "Bearer", "session=secret", "abc"} {
+		for _, entry := range entries {
+			for key, value := range entry.Fields {
+				if strings.Contains(value.(string), secret) {
+					t.Errorf("%s of %q leaks %q: %q", key, entry.Message, secret, value)
+				}
+			}
+		}
+	}
+
+	r.AssertField(t, "uri", "/?page=1&token=%5BREDACTED%5D")
+	r.AssertField(t, "body", `{"cards":[{"cvv":"[REDACTED]","number":"[REDACTED]"}],"password":"[REDACTED]","user":"bob"}`)
+	r.AssertField(t, "body", "paid by [REDACTED]")
+}
+
+func TestRedactorJSONPaths(t *testing.T) {
+	redactor := middles.DefaultRedactor()
+	redactor.JSONPaths = append(redactor.JSONPaths, "cards.**.cvv")
+
+	tests := []struct {
+		body string
+		want string
+	}{
+		{`{"user":{"password":"x"}}`, `{"user":{"password":"[REDACTED]"}}`},
+		{`[{"a":[{"password":"x"}]}]`, `[{"a":[{"password":"[REDACTED]"}]}]`},
+		{`{"cards":[{"cvv":"1"},{"meta":{"cvv":"2"}}],"cvv":"3"}`, `{"cards":[{"cvv":"[REDACTED]"},{"meta":{"cvv":"[REDACTED]"}}],"cvv":"3"}`},
+		{"", ""},
+		{" \n", " \n"},
+	}
+
+	for _, tt := range tests {
+		if got := redactor.Body("application/json", []byte(tt.body)); got != tt.want {
+			t.Errorf("body: %q, got: %q, want: %q", tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestRedactorUnparseable(t *testing.T) {
+	redactor := middles.DefaultRedactor()
+
+	tests := []struct {
+		contentType string
+		body        string
+		want        string
+	}{
+		{"application/json", `{"password":"hunter2"`, middles.UnparseableBody},
+		{"application/x-www-form-urlencoded", "password=x&y=%zz", middles.UnparseableBody},
+		{"application/x-www-form-urlencoded", "password=x&y=z", "password=%5BREDACTED%5D&y=z"},
+		{"text/plain", `{"password":"hunter2"`, `{"password":"hunter2"`},
+	}
+
+	for _, tt := range tests {
+		if got := redactor.Body(tt.contentType, []byte(tt.body)); got != tt.want {
+			t.Errorf("body: %q, got: %q, want: %q", tt.body, got, tt.want)
+		}
+	}
+
+	u, _ := url.Parse("/?password=x&y=%zz")
+	if got, want := redactor.URI(u), "/?"+middles.RedactedMask; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+}
+
+func TestLoggingWithoutRedaction(t *testing.T) {
+	l, r := conflogtest.New(t)
+
+	gin.SetMode(gin.ReleaseMode)
+
+	engine := gin.New()
+	engine.Use(middles.LoggingRequest(l, middles.WithRedactor(nil)))
+	engine.GET("/", func(c *gin.Context) {})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+
+	engine.ServeHTTP(httptest.NewRecorder(), req)
+
+	r.AssertField(t, "headers", "Authorization: Bearer secret")
+}
+
+func TestLoggingBodyLimit(t *testing.T) {
+	l, r := conflogtest.New(t)
+
+	gin.SetMode(gin.ReleaseMode)
+
+	var received string
+
+	engine := gin.New()
+	engine.Use(middles.LoggingRequest(l, middles.WithMaxBody(4)), middles.LoggingResponse(l, middles.WithMaxBody(4)))
+	engine.POST("/", func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		received = string(body)
+		c.String(http.StatusOK, "0123456789")
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("abcdefgh"))
+	req.Header.Set("Content-Type", "text/plain")
+
+	engine.ServeHTTP(httptest.NewRecorder(), req)
+
+	if got, want := received, "abcdefgh"; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+
+	r.AssertField(t, "body", "abcd...[truncated, 8 bytes total]")
+	r.AssertField(t, "body", "0123...[truncated, 10 bytes total]")
+}
+
+func TestLoggingBodyLimitRedaction(t *testing.T) {
+	l, r := conflogtest.New(t)
+
+	gin.SetMode(gin.ReleaseMode)
+
+	engine := gin.New()
+	engine.Use(middles.LoggingRequest(l, middles.WithMaxBody(30)))
+	engine.POST("/", func(c *gin.Context) {})
+
+	body := `{"user":"bob","password":"hunter2"}`
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	engine.ServeHTTP(httptest.NewRecorder(), req)
+
+	r.AssertField(t, "body", middles.UnparseableBody+"...[truncated, 35 bytes total]")
+}
+
+func TestLoggingBinaryBody(t *testing.T) {
+	l, r := conflogtest.New(t)
+
+	gin.SetMode(gin.ReleaseMode)
+
+	engine := gin.New()
+	engine.Use(middles.LoggingResponse(l), middles.LoggingResponse(l, middles.WithBinaryBody(middles.BinaryBodyBase64)))
+	engine.GET("/", func(c *gin.Context) {
+		c.Data(http.StatusOK, "image/png", []byte{0x89, 'P', 'N', 'G'})
+	})
+
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	r.AssertField(t, "body", "[binary image/png, 4 bytes]")
+	r.AssertField(t, "body", "iVBORw==")
+}
+
+func TestLoggingSkipPaths(t *testing.T) {
+	l, r := conflogtest.New(t)
+
+	gin.SetMode(gin.ReleaseMode)
+
+	engine := gin.New()
+	engine.Use(middles.LoggingRequest(l, middles.WithSkipPaths("/healthz")))
+	engine.GET("/healthz", func(c *gin.Context) {})
+	engine.GET("/users/:id", func(c *gin.Context) {})
+
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/1", nil))
+
+	if got, want := len(r.Entries()), 1; got != want {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+
+	r.AssertField(t, "uri", "/users/1")
+}
@@ -1,21 +1,138 @@
 package middles
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
-	"runtime/debug"
+	"syscall"
 
 	"github.com/gin-gonic/gin"
 	"github.com/rbee3u/gohelp/conflog"
+	"github.com/rbee3u/gohelp/epkg"
 )
 
-func Recovery(l *conflog.Logger) gin.HandlerFunc {
+// ProblemContentType is the content type of Problem defined by RFC 7807.
+const ProblemContentType = "application/problem+json"
+
+// Problem is the body of the default recovery response.
+type Problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Instance  string `json:"instance,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// PanicReporter forwards a recovered panic elsewhere, e.g. to an error
+// tracker, err carries the stack of the panic, see epkg.StackTrace.
+type PanicReporter func(c *gin.Context, err error)
+
+// RecoveryResponder writes the response of a recovered panic.
+type RecoveryResponder func(c *gin.Context, err error)
+
+type recovery struct {
+	responder RecoveryResponder
+	reporters []PanicReporter
+}
+
+type RecoveryOption func(*recovery)
+
+// WithRecoveryResponder replaces the default responder, which responds 500
+// with a Problem carrying the request id.
+func WithRecoveryResponder(responder RecoveryResponder) RecoveryOption {
+	return func(r *recovery) {
+		r.responder = responder
+	}
+}
+
+// WithPanicReporter adds a reporter, which is called for every recovered
+// panic except those caused by broken connections.
+func WithPanicReporter(reporter PanicReporter) RecoveryOption {
+	return func(r *recovery) {
+		r.reporters = append(r.reporters, reporter)
+	}
+}
+
+// Recovery recovers panics of the handlers, the panic value is converted to
+// an error with the stack of the panic, and logged with it. A panic caused
+// by a broken connection, e.g. a client gone away, is logged as a warning
+// and aborts without responding, since nobody is there to read it. The
+// http.ErrAbortHandler is panicked again, so that net/http aborts the
+// response as the handler intends.
+func Recovery(l *conflog.Logger, opts ...RecoveryOption) gin.HandlerFunc {
+	r := &recovery{responder: respondProblem}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
 	return func(c *gin.Context) {
 		defer func() {
-			if err := recover(); err != nil {
-				l.Errorf("panic recovered: err = %v, stack = %s", err, debug.Stack())
-				c.AbortWithStatus(http.StatusInternalServerError)
+			value := recover()
+			if value == nil {
+				return
+			}
+
+			if err, ok := value.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+				panic(value)
+			}
+
+			err := epkg.StackWithSkip(panicError(value), 2)
+			entry := l.WithContext(c.Request.Context()).WithError(err)
+
+			if brokenConnection(value) {
+				entry.Warn("connection broken")
+				c.Abort()
+
+				return
+			}
+
+			entry.Error("panic recovered")
+
+			for _, reporter := range r.reporters {
+				reporter(c, err)
 			}
+
+			if c.Writer.Written() {
+				c.Abort()
+
+				return
+			}
+
+			r.responder(c, err)
+			c.Abort()
 		}()
 		c.Next()
 	}
 }
+
+func panicError(value interface{}) error {
+	if err, ok := value.(error); ok {
+		return fmt.Errorf("panic: %w", err)
+	}
+
+	return fmt.Errorf("panic: %v", value)
+}
+
+// brokenConnection reports whether the panic is caused by a broken
+// connection, which is not a bug of the handler.
+func brokenConnection(value interface{}) bool {
+	err, ok := value.(error)
+	if !ok {
+		return false
+	}
+
+	return errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, syscall.ECONNRESET)
+}
+
+func respondProblem(c *gin.Context, _ error) {
+	c.Header("Content-Type", ProblemContentType)
+	c.JSON(http.StatusInternalServerError, Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(http.StatusInternalServerError),
+		Status:    http.StatusInternalServerError,
+		Instance:  c.Request.URL.Path,
+		RequestID: RequestIDFromContext(c.Request.Context()),
+	})
+}
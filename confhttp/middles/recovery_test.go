@@ -0,0 +1,146 @@
+package middles_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rbee3u/gohelp/confhttp/middles"
+	"github.com/rbee3u/gohelp/conflog"
+	"github.com/rbee3u/gohelp/conflog/conflogtest"
+	"github.com/rbee3u/gohelp/epkg"
+	"github.com/sirupsen/logrus"
+)
+
+func TestRecovery(t *testing.T) {
+	l, r := conflogtest.New(t)
+
+	gin.SetMode(gin.ReleaseMode)
+
+	var reported error
+
+	engine := gin.New()
+	engine.Use(middles.RequestID(l), middles.Recovery(l, middles.WithPanicReporter(func(c *gin.Context, err error) {
+		reported = err
+	})))
+	engine.GET("/boom", func(c *gin.Context) {
+		panic("boom")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/boom", nil)
+	req.Header.Set(middles.RequestIDHeader, "abc")
+
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, req)
+
+	if got, want := rec.Code, http.StatusInternalServerError; got != want {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+
+	if got, want := rec.Header().Get("Content-Type"), middles.ProblemContentType; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+
+	var problem middles.Problem
+	if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+
+	if got, want := problem, (middles.Problem{
+		Type: "about:blank", Title: "Internal Server Error", Status: 500, Instance: "/boom", RequestID: "abc",
+	}); got != want {
+		t.Errorf("got: %+v, want: %+v", got, want)
+	}
+
+	if reported == nil || reported.Error() != "panic: boom" {
+		t.Fatalf("unexpected reported: %v", reported)
+	}
+
+	if got, want := fmt.Sprint(epkg.StackTrace(reported)), "TestRecovery.func2"; !strings.Contains(got, want) {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+
+	r.AssertCount(t, logrus.ErrorLevel, 1)
+	r.AssertField(t, logrus.ErrorKey, "panic: boom")
+	r.AssertField(t, conflog.ErrorStackField, conflog.StringList(epkg.StackTrace(reported)))
+}
+
+func TestRecoveryBrokenConnection(t *testing.T) {
+	l, r := conflogtest.New(t)
+
+	gin.SetMode(gin.ReleaseMode)
+
+	reported := false
+
+	engine := gin.New()
+	engine.Use(middles.Recovery(l, middles.WithPanicReporter(func(c *gin.Context, err error) {
+		reported = true
+	})))
+	engine.GET("/", func(c *gin.Context) {
+		panic(&os.SyscallError{Syscall: "write", Err: syscall.EPIPE})
+	})
+
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if reported {
+		t.Errorf("got: %v, want: %v", reported, false)
+	}
+
+	if got := rec.Body.Len(); got != 0 {
+		t.Errorf("got: %v, want: %v", got, 0)
+	}
+
+	r.AssertCount(t, logrus.WarnLevel, 1)
+	r.AssertCount(t, logrus.ErrorLevel, 0)
+}
+
+func TestRecoveryAbortHandler(t *testing.T) {
+	l, r := conflogtest.New(t)
+
+	gin.SetMode(gin.ReleaseMode)
+
+	engine := gin.New()
+	engine.Use(middles.Recovery(l))
+	engine.GET("/", func(c *gin.Context) {
+		panic(http.ErrAbortHandler)
+	})
+
+	defer func() {
+		if got, want := recover(), http.ErrAbortHandler; got != want {
+			t.Errorf("got: %v, want: %v", got, want)
+		}
+
+		r.AssertCount(t, logrus.WarnLevel, 0)
+		r.AssertCount(t, logrus.ErrorLevel, 0)
+	}()
+
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
+
+func TestRecoveryResponder(t *testing.T) {
+	l, _ := conflogtest.New(t)
+
+	gin.SetMode(gin.ReleaseMode)
+
+	engine := gin.New()
+	engine.Use(middles.Recovery(l, middles.WithRecoveryResponder(func(c *gin.Context, err error) {
+		c.String(http.StatusServiceUnavailable, "sorry")
+	})))
+	engine.GET("/", func(c *gin.Context) {
+		panic("boom")
+	})
+
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if got, want := rec.Code, http.StatusServiceUnavailable; got != want {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+}
@@ -0,0 +1,249 @@
+package middles
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	// RedactedMask is the default mask of the redacted values.
+	RedactedMask = "[REDACTED]"
+	// CardNumberPattern matches the payment card numbers of 13 to 19 digits,
+	// which may be separated by spaces or dashes.
+	CardNumberPattern = `\b(?:\d[ -]?){12,18}\d\b`
+	// UnparseableBody replaces the JSON and form bodies which can not be
+	// parsed, since their sensitive values can not be found.
+	UnparseableBody = "[unparseable body]"
+)
+
+// Redactor masks the sensitive data in the requests and responses before
+// they are logged.
+type Redactor struct {
+	// Headers are the names of headers to redact, case-insensitively.
+	Headers []string
+	// JSONPaths are the dotted paths of the values to redact in JSON bodies,
+	// where "*" matches any key or index, and "**" matches any number of
+	// them, e.g. "password" matches the top level key only, while
+	// "**.password" matches the key at any depth, "cards.*.number".
+	JSONPaths []string
+	// FormFields are the names of the fields to redact in form bodies and
+	// query strings.
+	FormFields []string
+	// Patterns are applied to all logged values after the others, e.g.
+	// regexp.MustCompile(CardNumberPattern).
+	Patterns []*regexp.Regexp
+	// Mask replaces the redacted values, it is RedactedMask if empty.
+	Mask string
+}
+
+// DefaultRedactor redacts the credentials in headers, and the password in
+// JSON bodies at any depth and in form bodies.
+func DefaultRedactor() *Redactor {
+	return &Redactor{
+		Headers:    []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"},
+		JSONPaths:  []string{"**.password"},
+		FormFields: []string{"password"},
+	}
+}
+
+func (r *Redactor) mask() string {
+	if len(r.Mask) == 0 {
+		return RedactedMask
+	}
+
+	return r.Mask
+}
+
+// Header returns a copy of headers with the sensitive values redacted.
+func (r *Redactor) Header(headers http.Header) http.Header {
+	redacted := make(http.Header, len(headers))
+
+	for key, values := range headers {
+		if r.isHeader(key) {
+			redacted[key] = []string{r.mask()}
+
+			continue
+		}
+
+		redacted[key] = make([]string, len(values))
+		for i, value := range values {
+			redacted[key][i] = r.String(value)
+		}
+	}
+
+	return redacted
+}
+
+func (r *Redactor) isHeader(key string) bool {
+	for _, header := range r.Headers {
+		if strings.EqualFold(header, key) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// URI returns the request uri of u with the sensitive query fields redacted.
+func (r *Redactor) URI(u *url.URL) string {
+	if len(u.RawQuery) == 0 {
+		return r.String(u.RequestURI())
+	}
+
+	redacted := *u
+
+	query, ok := r.form(u.RawQuery)
+	if !ok {
+		query = r.mask()
+	}
+
+	redacted.RawQuery = query
+
+	return r.String(redacted.RequestURI())
+}
+
+// Body returns body with the sensitive values redacted according to
+// contentType, JSON and form bodies which can not be parsed are replaced
+// with UnparseableBody.
+func (r *Redactor) Body(contentType string, body []byte) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	text, ok := string(body), true
+
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		text, ok = r.json(body)
+	case mediaType == "application/x-www-form-urlencoded":
+		text, ok = r.form(text)
+	}
+
+	if !ok {
+		return UnparseableBody
+	}
+
+	return r.String(text)
+}
+
+// String returns s with the matches of Patterns redacted.
+func (r *Redactor) String(s string) string {
+	for _, pattern := range r.Patterns {
+		s = pattern.ReplaceAllString(s, r.mask())
+	}
+
+	return s
+}
+
+// json redacts JSONPaths in body, it reports false if body can not be
+// parsed.
+func (r *Redactor) json(body []byte) (string, bool) {
+	if len(r.JSONPaths) == 0 || len(bytes.TrimSpace(body)) == 0 {
+		return string(body), true
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return "", false
+	}
+
+	for _, path := range r.JSONPaths {
+		redactPath(v, strings.Split(path, "."), r.mask())
+	}
+
+	buf := new(bytes.Buffer)
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+
+	if err := encoder.Encode(v); err != nil {
+		return "", false
+	}
+
+	return strings.TrimSuffix(buf.String(), "\n"), true
+}
+
+func redactPath(v interface{}, segments []string, mask string) {
+	if segments[0] == "**" {
+		redactAnyDepth(v, segments, mask)
+
+		return
+	}
+
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for key, child := range t {
+			if segments[0] != "*" && !strings.EqualFold(segments[0], key) {
+				continue
+			}
+
+			if len(segments) == 1 {
+				t[key] = mask
+			} else {
+				redactPath(child, segments[1:], mask)
+			}
+		}
+	case []interface{}:
+		for i, child := range t {
+			if segments[0] != "*" && segments[0] != strconv.Itoa(i) {
+				continue
+			}
+
+			if len(segments) == 1 {
+				t[i] = mask
+			} else {
+				redactPath(child, segments[1:], mask)
+			}
+		}
+	}
+}
+
+// form redacts FormFields in text, it reports false if text can not be
+// parsed.
+// redactAnyDepth applies the segments after "**" to v and all its
+// descendants.
+func redactAnyDepth(v interface{}, segments []string, mask string) {
+	if len(segments) == 1 {
+		return
+	}
+
+	redactPath(v, segments[1:], mask)
+
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for _, child := range t {
+			redactAnyDepth(child, segments, mask)
+		}
+	case []interface{}:
+		for _, child := range t {
+			redactAnyDepth(child, segments, mask)
+		}
+	}
+}
+
+func (r *Redactor) form(text string) (string, bool) {
+	if len(r.FormFields) == 0 {
+		return text, true
+	}
+
+	values, err := url.ParseQuery(text)
+	if err != nil {
+		return "", false
+	}
+
+	for key := range values {
+		for _, field := range r.FormFields {
+			if strings.EqualFold(field, key) {
+				values[key] = []string{r.mask()}
+			}
+		}
+	}
+
+	return values.Encode(), true
+}
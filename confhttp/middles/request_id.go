@@ -0,0 +1,69 @@
+package middles
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rbee3u/gohelp/conflog"
+)
+
+const (
+	RequestIDHeader = "X-Request-ID"
+	RequestIDField  = "request_id"
+
+	maxRequestIDLen = 128
+)
+
+type requestIDKey struct{}
+
+// RequestIDFromContext returns the request id carried by ctx, or an empty
+// string if there is none.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+
+	return id
+}
+
+// RequestID reads the request id from the X-Request-ID header, or generates
+// a new one if it is missing or malformed, then stores it in the request
+// context and echoes it in the response header. Every entry of l logged
+// with that context carries the request id as field.
+func RequestID(l *conflog.Logger) gin.HandlerFunc {
+	l.AddContextField(RequestIDField, requestIDKey{})
+
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+
+		ctx := context.WithValue(c.Request.Context(), requestIDKey{}, id)
+		c.Request = c.Request.WithContext(ctx)
+		c.Header(RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+func validRequestID(id string) bool {
+	if len(id) == 0 || len(id) > maxRequestIDLen {
+		return false
+	}
+
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+
+	return true
+}
+
+func newRequestID() string {
+	var b [16]byte
+
+	_, _ = rand.Read(b[:])
+
+	return hex.EncodeToString(b[:])
+}
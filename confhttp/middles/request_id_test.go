@@ -0,0 +1,59 @@
+package middles_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rbee3u/gohelp/confhttp/middles"
+	"github.com/rbee3u/gohelp/conflog/conflogtest"
+)
+
+func TestRequestID(t *testing.T) {
+	l, r := conflogtest.New(t)
+
+	gin.SetMode(gin.ReleaseMode)
+
+	engine := gin.New()
+	engine.Use(middles.RequestID(l), middles.LoggingResponse(l))
+	engine.GET("/", func(c *gin.Context) {
+		l.WithContext(c.Request.Context()).Info("handling")
+		c.String(http.StatusOK, middles.RequestIDFromContext(c.Request.Context()))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(middles.RequestIDHeader, "abc")
+
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, req)
+
+	if got, want := rec.Header().Get(middles.RequestIDHeader), "abc"; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+
+	if got, want := rec.Body.String(), "abc"; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+
+	entries := r.Entries()
+	if got, want := len(entries), 2; got != want {
+		t.Fatalf("got: %v, want: %v", got, want)
+	}
+
+	for _, entry := range entries {
+		if got, want := entry.Fields[middles.RequestIDField], "abc"; got != want {
+			t.Errorf("got: %q, want: %q", got, want)
+		}
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(middles.RequestIDHeader, "bad id")
+
+	rec = httptest.NewRecorder()
+	engine.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get(middles.RequestIDHeader); len(got) != 32 {
+		t.Errorf("expect a generated request id, got: %q", got)
+	}
+}
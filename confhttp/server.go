@@ -2,15 +2,73 @@ package confhttp
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/rbee3u/gohelp/confhttp/middles"
 )
 
 type Server struct {
 	kernel *gin.Engine `env:"-"`
 	Port   int32       `env:""`
+
+	// TLSCert and TLSKey are the files of the certificate and its key, the
+	// server serves HTTPS if they are not empty.
+	TLSCert string `env:""`
+	TLSKey  string `env:""`
+	// TLSMinVersion is one of "1.0", "1.1", "1.2" and "1.3".
+	TLSMinVersion string `env:""`
+	// TLSCipherSuites is the comma separated names of cipher suites, the
+	// default ones of Go are used if it is empty.
+	TLSCipherSuites string `env:""`
+	// TLSClientCA is the file of the CA bundle to verify the certificates
+	// of clients, TLSClientAuth is one of "require" and "verify-if-given".
+	TLSClientCA   string `env:""`
+	TLSClientAuth string `env:""`
+	// TLSReloadInterval is the interval at which the files are checked for
+	// changes and reloaded, "0s" disables reloading.
+	TLSReloadInterval string `env:""`
+
+	// ReadTimeout, ReadHeaderTimeout, WriteTimeout and IdleTimeout are those
+	// of http.Server, which protect the server from slow clients. The
+	// WriteTimeout is "0s" by default, which leaves the handlers to be
+	// bounded per route by middles.Deadline, a non-zero one cuts every
+	// response longer than it regardless of the routes.
+	ReadTimeout       string `env:""`
+	ReadHeaderTimeout string `env:""`
+	WriteTimeout      string `env:""`
+	IdleTimeout       string `env:""`
+	// MaxHeaderBytes limits the size of request headers.
+	MaxHeaderBytes int `env:""`
+	// MaxBodyBytes limits the size of request bodies by the middleware
+	// returned by BodyLimit, negative disables it.
+	MaxBodyBytes int64 `env:""`
+
+	// ShutdownDelay is the duration between the readiness flip and the
+	// draining, for load balancers to notice the server is not ready.
+	ShutdownDelay string `env:""`
+	// ShutdownTimeout is the grace period to drain the connections.
+	ShutdownTimeout string `env:""`
+
+	tlsConfig         *tls.Config
+	readTimeout       time.Duration
+	readHeaderTimeout time.Duration
+	writeTimeout      time.Duration
+	idleTimeout       time.Duration
+	shutdownDelay     time.Duration
+	shutdownTimeout   time.Duration
+	ready             atomic.Bool
+	conns             atomic.Int64
+
+	mu    sync.Mutex
+	drain DrainStats
 }
 
 type ServerOption func(*Server)
@@ -21,6 +79,96 @@ func WithPort(port int32) ServerOption {
 	}
 }
 
+func WithTLSCert(tlsCert string) ServerOption {
+	return func(s *Server) {
+		s.TLSCert = tlsCert
+	}
+}
+
+func WithTLSKey(tlsKey string) ServerOption {
+	return func(s *Server) {
+		s.TLSKey = tlsKey
+	}
+}
+
+func WithTLSMinVersion(tlsMinVersion string) ServerOption {
+	return func(s *Server) {
+		s.TLSMinVersion = tlsMinVersion
+	}
+}
+
+func WithTLSCipherSuites(tlsCipherSuites string) ServerOption {
+	return func(s *Server) {
+		s.TLSCipherSuites = tlsCipherSuites
+	}
+}
+
+func WithTLSClientCA(tlsClientCA string) ServerOption {
+	return func(s *Server) {
+		s.TLSClientCA = tlsClientCA
+	}
+}
+
+func WithTLSClientAuth(tlsClientAuth string) ServerOption {
+	return func(s *Server) {
+		s.TLSClientAuth = tlsClientAuth
+	}
+}
+
+func WithTLSReloadInterval(tlsReloadInterval string) ServerOption {
+	return func(s *Server) {
+		s.TLSReloadInterval = tlsReloadInterval
+	}
+}
+
+func WithReadTimeout(readTimeout string) ServerOption {
+	return func(s *Server) {
+		s.ReadTimeout = readTimeout
+	}
+}
+
+func WithReadHeaderTimeout(readHeaderTimeout string) ServerOption {
+	return func(s *Server) {
+		s.ReadHeaderTimeout = readHeaderTimeout
+	}
+}
+
+func WithWriteTimeout(writeTimeout string) ServerOption {
+	return func(s *Server) {
+		s.WriteTimeout = writeTimeout
+	}
+}
+
+func WithIdleTimeout(idleTimeout string) ServerOption {
+	return func(s *Server) {
+		s.IdleTimeout = idleTimeout
+	}
+}
+
+func WithMaxHeaderBytes(maxHeaderBytes int) ServerOption {
+	return func(s *Server) {
+		s.MaxHeaderBytes = maxHeaderBytes
+	}
+}
+
+func WithMaxBodyBytes(maxBodyBytes int64) ServerOption {
+	return func(s *Server) {
+		s.MaxBodyBytes = maxBodyBytes
+	}
+}
+
+func WithShutdownDelay(shutdownDelay string) ServerOption {
+	return func(s *Server) {
+		s.ShutdownDelay = shutdownDelay
+	}
+}
+
+func WithShutdownTimeout(shutdownTimeout string) ServerOption {
+	return func(s *Server) {
+		s.ShutdownTimeout = shutdownTimeout
+	}
+}
+
 func New(opts ...ServerOption) (*Server, error) {
 	s := &Server{}
 
@@ -44,6 +192,50 @@ func (s *Server) SetDefaults() error {
 		s.Port = 80
 	}
 
+	if len(s.TLSMinVersion) == 0 {
+		s.TLSMinVersion = "1.2"
+	}
+
+	if len(s.TLSClientAuth) == 0 {
+		s.TLSClientAuth = "require"
+	}
+
+	if len(s.TLSReloadInterval) == 0 {
+		s.TLSReloadInterval = "10s"
+	}
+
+	if len(s.ReadTimeout) == 0 {
+		s.ReadTimeout = "30s"
+	}
+
+	if len(s.ReadHeaderTimeout) == 0 {
+		s.ReadHeaderTimeout = "10s"
+	}
+
+	if len(s.WriteTimeout) == 0 {
+		s.WriteTimeout = "0s"
+	}
+
+	if len(s.IdleTimeout) == 0 {
+		s.IdleTimeout = "120s"
+	}
+
+	if s.MaxHeaderBytes == 0 {
+		s.MaxHeaderBytes = 1 << 20
+	}
+
+	if s.MaxBodyBytes == 0 {
+		s.MaxBodyBytes = 10 << 20
+	}
+
+	if len(s.ShutdownDelay) == 0 {
+		s.ShutdownDelay = "0s"
+	}
+
+	if len(s.ShutdownTimeout) == 0 {
+		s.ShutdownTimeout = "30s"
+	}
+
 	return nil
 }
 
@@ -52,21 +244,120 @@ func (s *Server) Initialize() error {
 
 	s.kernel = gin.New()
 
+	for _, timeout := range []struct {
+		text string
+		d    *time.Duration
+	}{
+		{text: s.ReadTimeout, d: &s.readTimeout},
+		{text: s.ReadHeaderTimeout, d: &s.readHeaderTimeout},
+		{text: s.WriteTimeout, d: &s.writeTimeout},
+		{text: s.IdleTimeout, d: &s.idleTimeout},
+	} {
+		d, err := time.ParseDuration(timeout.text)
+		if err != nil {
+			return fmt.Errorf("failed to parse timeout: %w", err)
+		}
+
+		*timeout.d = d
+	}
+
+	s.tlsConfig = nil
+
+	if len(s.TLSCert) != 0 || len(s.TLSKey) != 0 {
+		tlsConfig, err := s.newTLSConfig()
+		if err != nil {
+			return fmt.Errorf("failed to new tls config: %w", err)
+		}
+
+		s.tlsConfig = tlsConfig
+	}
+
+	shutdownDelay, err := time.ParseDuration(s.ShutdownDelay)
+	if err != nil {
+		return fmt.Errorf("failed to parse shutdown delay: %w", err)
+	}
+
+	shutdownTimeout, err := time.ParseDuration(s.ShutdownTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to parse shutdown timeout: %w", err)
+	}
+
+	s.shutdownDelay, s.shutdownTimeout = shutdownDelay, shutdownTimeout
+
 	return nil
 }
 
+// BodyLimit returns the middleware limiting request bodies to MaxBodyBytes,
+// it is left to be placed by the user, usually after the logging and
+// recovery middlewares, e.g. s.Kernel().Use(logging, recovery, s.BodyLimit()).
+func (s *Server) BodyLimit() gin.HandlerFunc {
+	if s.MaxBodyBytes < 0 {
+		return func(c *gin.Context) {}
+	}
+
+	return middles.BodyLimit(s.MaxBodyBytes)
+}
+
 func (s *Server) Kernel() *gin.Engine {
 	return s.kernel
 }
 
+// TLSConfig returns the tls.Config used to serve HTTPS, or nil if TLS is
+// not configured.
+func (s *Server) TLSConfig() *tls.Config {
+	return s.tlsConfig
+}
+
 func (s *Server) Serve(ctx context.Context) error {
-	addr := fmt.Sprintf(":%v", s.Port)
-	srv := &http.Server{Handler: s.kernel, Addr: addr}
+	ln, err := net.Listen("tcp", fmt.Sprintf(":%v", s.Port))
+	if err != nil {
+		return fmt.Errorf("failed to listen: %w", err)
+	}
+
+	return s.ServeListener(ctx, ln)
+}
+
+// ServeListener serves on ln until ctx is done, then shuts down gracefully
+// and returns nil if all the connections are drained in time.
+func (s *Server) ServeListener(ctx context.Context, ln net.Listener) error {
+	srv := &http.Server{
+		Handler:           s.kernel,
+		TLSConfig:         s.tlsConfig,
+		ReadTimeout:       s.readTimeout,
+		ReadHeaderTimeout: s.readHeaderTimeout,
+		WriteTimeout:      s.writeTimeout,
+		IdleTimeout:       s.idleTimeout,
+		MaxHeaderBytes:    s.MaxHeaderBytes,
+		ConnState:         s.trackConn,
+	}
+	done := make(chan error, 1)
+
+	// The server is ready before it may serve any request.
+	s.ready.Store(true)
+
+	go func() {
+		if s.tlsConfig != nil {
+			// The certificate is provided by the TLSConfig.
+			done <- srv.ServeTLS(ln, "", "")
+		} else {
+			done <- srv.Serve(ln)
+		}
+	}()
+
+	select {
+	case err := <-done:
+		s.ready.Store(false)
 
-	go func() { <-ctx.Done(); _ = srv.Shutdown(ctx) }()
+		return fmt.Errorf("failed to serve: %w", err)
+	case <-ctx.Done():
+	}
+
+	if err := s.shutdown(srv); err != nil {
+		return err
+	}
 
-	if err := srv.ListenAndServe(); err != nil {
-		return fmt.Errorf("failed to listen and serve: %w", err)
+	if err := <-done; !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("failed to serve: %w", err)
 	}
 
 	return nil
@@ -0,0 +1,72 @@
+package confhttp_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rbee3u/gohelp/confhttp"
+)
+
+func TestServerMaxBodyBytes(t *testing.T) {
+	s, err := confhttp.New(confhttp.WithMaxBodyBytes(4))
+	if err != nil {
+		t.Fatalf("failed to new: %v", err)
+	}
+
+	s.Kernel().Use(s.BodyLimit())
+	s.Kernel().POST("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	rec := httptest.NewRecorder()
+	s.Kernel().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("abcdefgh")))
+
+	if got, want := rec.Code, http.StatusRequestEntityTooLarge; got != want {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+}
+
+func TestServerReadHeaderTimeout(t *testing.T) {
+	s, err := confhttp.New(confhttp.WithReadHeaderTimeout("50ms"))
+	if err != nil {
+		t.Fatalf("failed to new: %v", err)
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() { _ = s.ServeListener(ctx, ln) }()
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer conn.Close()
+
+	// A slowloris client which never finishes the headers.
+	_, _ = io.WriteString(conn, "GET / HTTP/1.1\r\nHost: localhost\r\n")
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	start := time.Now()
+	_, _ = io.ReadAll(conn)
+
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("got: %v, want: closed by read header timeout", elapsed)
+	}
+}
+
+func TestServerInvalidTimeout(t *testing.T) {
+	if _, err := confhttp.New(confhttp.WithWriteTimeout("forever")); err == nil {
+		t.Errorf("got: %v, want: error", err)
+	}
+}
@@ -0,0 +1,95 @@
+package confhttp
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DrainStats describes the last graceful shutdown, Connections is the
+// number of connections open when draining began, and Forced is the number
+// of them closed forcibly once ShutdownTimeout expired.
+type DrainStats struct {
+	Connections int64
+	Forced      int64
+	Duration    time.Duration
+}
+
+// Ready reports whether the server is serving and not draining.
+func (s *Server) Ready() bool {
+	return s.ready.Load()
+}
+
+// ReadinessHandler responds 200 if the server is ready, or 503 once it
+// begins to shut down, so that load balancers stop routing to it before
+// the connections are drained.
+func (s *Server) ReadinessHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !s.Ready() {
+			c.String(http.StatusServiceUnavailable, "not ready")
+
+			return
+		}
+
+		c.String(http.StatusOK, "ready")
+	}
+}
+
+// Connections returns the number of open connections.
+func (s *Server) Connections() int64 {
+	return s.conns.Load()
+}
+
+// LastDrain returns the stats of the last graceful shutdown.
+func (s *Server) LastDrain() DrainStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.drain
+}
+
+func (s *Server) trackConn(_ net.Conn, state http.ConnState) {
+	switch state {
+	case http.StateNew:
+		s.conns.Add(1)
+	case http.StateClosed, http.StateHijacked:
+		s.conns.Add(-1)
+	case http.StateActive, http.StateIdle:
+	}
+}
+
+// shutdown flips the readiness, waits for ShutdownDelay, then drains the
+// connections within ShutdownTimeout, after which they are closed forcibly.
+func (s *Server) shutdown(srv *http.Server) error {
+	s.ready.Store(false)
+
+	time.Sleep(s.shutdownDelay)
+
+	start := time.Now()
+	drain := DrainStats{Connections: s.conns.Load()}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	defer cancel()
+
+	err := srv.Shutdown(ctx)
+	if err != nil {
+		drain.Forced = s.conns.Load()
+		_ = srv.Close()
+	}
+
+	drain.Duration = time.Since(start)
+
+	s.mu.Lock()
+	s.drain = drain
+	s.mu.Unlock()
+
+	if err != nil {
+		return fmt.Errorf("failed to shutdown: %w", err)
+	}
+
+	return nil
+}
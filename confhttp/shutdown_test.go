@@ -0,0 +1,121 @@
+package confhttp_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rbee3u/gohelp/confhttp"
+)
+
+func TestServerGracefulShutdown(t *testing.T) {
+	s, err := confhttp.New(confhttp.WithShutdownTimeout("5s"), confhttp.WithShutdownDelay("10ms"))
+	if err != nil {
+		t.Fatalf("failed to new: %v", err)
+	}
+
+	entered, release := make(chan struct{}), make(chan struct{})
+
+	s.Kernel().GET("/ready", s.ReadinessHandler())
+	s.Kernel().GET("/slow", func(c *gin.Context) {
+		close(entered)
+		<-release
+		c.String(http.StatusOK, "done")
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+
+	go func() { served <- s.ServeListener(ctx, ln) }()
+
+	body := make(chan string, 1)
+
+	go func() {
+		resp, err := http.Get("http://" + ln.Addr().String() + "/slow")
+		if err != nil {
+			body <- err.Error()
+
+			return
+		}
+		defer resp.Body.Close()
+
+		data, _ := io.ReadAll(resp.Body)
+		body <- string(data)
+	}()
+
+	<-entered
+
+	if !s.Ready() || s.Connections() != 1 {
+		t.Errorf("got: %v %v, want: true 1", s.Ready(), s.Connections())
+	}
+
+	cancel()
+
+	for deadline := time.Now().Add(time.Second); s.Ready() && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+
+	if s.Ready() {
+		t.Errorf("got: %v, want: %v", s.Ready(), false)
+	}
+
+	close(release)
+
+	if got, want := <-body, "done"; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+
+	if err := <-served; err != nil {
+		t.Errorf("got: %v, want: nil", err)
+	}
+
+	if got := s.LastDrain(); got.Connections != 1 || got.Forced != 0 {
+		t.Errorf("unexpected drain: %+v", got)
+	}
+}
+
+func TestServerShutdownTimeout(t *testing.T) {
+	s, err := confhttp.New(confhttp.WithShutdownTimeout("10ms"))
+	if err != nil {
+		t.Fatalf("failed to new: %v", err)
+	}
+
+	entered, release := make(chan struct{}), make(chan struct{})
+	defer close(release)
+
+	s.Kernel().GET("/stuck", func(c *gin.Context) {
+		close(entered)
+		<-release
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+
+	go func() { served <- s.ServeListener(ctx, ln) }()
+	go func() { _, _ = http.Get("http://" + ln.Addr().String() + "/stuck") }()
+
+	<-entered
+	cancel()
+
+	if err := <-served; err == nil {
+		t.Errorf("got: %v, want: error", err)
+	}
+
+	if got := s.LastDrain(); got.Forced != 1 {
+		t.Errorf("unexpected drain: %+v", got)
+	}
+}
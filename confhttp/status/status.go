@@ -34,6 +34,10 @@ func (e *baseError) Error() string {
 	return e.message
 }
 
+func (e *baseError) Code() int {
+	return e.code
+}
+
 func (e *baseError) Format(s fmt.State, verb rune) {
 	switch verb {
 	case 'v', 's':
@@ -89,6 +93,10 @@ func (e *wrapError) Unwrap() error {
 	return e.err
 }
 
+func (e *wrapError) Code() int {
+	return e.code
+}
+
 func (e *wrapError) Format(s fmt.State, verb rune) {
 	switch verb {
 	case 'v':
@@ -0,0 +1,243 @@
+package confhttp
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// newTLSConfig creates the tls.Config according to the TLS fields, the
+// certificate and the client CA are reloaded once their files change.
+func (s *Server) newTLSConfig() (*tls.Config, error) {
+	minVersion, err := parseTLSVersion(s.TLSMinVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	cipherSuites, err := parseCipherSuites(s.TLSCipherSuites)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &tls.Config{MinVersion: minVersion, CipherSuites: cipherSuites}
+
+	// The client certificates are verified by VerifyConnection against the
+	// reloaded CA, rather than by the static ClientCAs.
+	if len(s.TLSClientCA) != 0 {
+		switch s.TLSClientAuth {
+		case "require":
+			config.ClientAuth = tls.RequireAnyClientCert
+		case "verify-if-given":
+			config.ClientAuth = tls.RequestClientCert
+		default:
+			return nil, fmt.Errorf("invalid tls client auth: %q", s.TLSClientAuth)
+		}
+	}
+
+	interval, err := time.ParseDuration(s.TLSReloadInterval)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse tls reload interval: %w", err)
+	}
+
+	reloader := &certReloader{
+		certFile: s.TLSCert,
+		keyFile:  s.TLSKey,
+		caFile:   s.TLSClientCA,
+		interval: interval,
+	}
+
+	if err := reloader.load(); err != nil {
+		return nil, err
+	}
+
+	config.GetCertificate = func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+		cert, _ := reloader.get()
+
+		return cert, nil
+	}
+
+	if len(s.TLSClientCA) != 0 {
+		config.VerifyConnection = func(cs tls.ConnectionState) error {
+			_, clientCAs := reloader.get()
+
+			return verifyClient(cs, clientCAs)
+		}
+	}
+
+	return config, nil
+}
+
+func verifyClient(cs tls.ConnectionState, clientCAs *x509.CertPool) error {
+	if len(cs.PeerCertificates) == 0 {
+		// The absence is rejected by the handshake if it is required.
+		return nil
+	}
+
+	opts := x509.VerifyOptions{
+		Roots:         clientCAs,
+		Intermediates: x509.NewCertPool(),
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	for _, cert := range cs.PeerCertificates[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+
+	if _, err := cs.PeerCertificates[0].Verify(opts); err != nil {
+		return fmt.Errorf("failed to verify client certificate: %w", err)
+	}
+
+	return nil
+}
+
+// certReloader keeps the certificate and the client CA, which are reloaded
+// on handshakes if their files have been modified, checked at most once
+// every interval, and never if interval is zero.
+type certReloader struct {
+	certFile string
+	keyFile  string
+	caFile   string
+	interval time.Duration
+
+	mu        sync.Mutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+	modTimes  []time.Time
+	checkedAt time.Time
+}
+
+func (r *certReloader) get() (*tls.Certificate, *x509.CertPool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.interval > 0 && time.Since(r.checkedAt) >= r.interval {
+		r.checkedAt = time.Now()
+
+		if modTimes := r.stat(); !equalTimes(modTimes, r.modTimes) {
+			// The files may be replaced one by one, the old ones are kept
+			// until all of them are consistent again.
+			_ = r.loadLocked()
+		}
+	}
+
+	return r.cert, r.clientCAs
+}
+
+func (r *certReloader) load() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.checkedAt = time.Now()
+
+	return r.loadLocked()
+}
+
+func (r *certReloader) loadLocked() error {
+	modTimes := r.stat()
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load x509 key pair: %w", err)
+	}
+
+	var clientCAs *x509.CertPool
+
+	if len(r.caFile) != 0 {
+		pem, err := os.ReadFile(r.caFile)
+		if err != nil {
+			return fmt.Errorf("failed to read client ca: %w", err)
+		}
+
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("invalid client ca: %q", r.caFile)
+		}
+	}
+
+	r.cert, r.clientCAs, r.modTimes = &cert, clientCAs, modTimes
+
+	return nil
+}
+
+func (r *certReloader) stat() []time.Time {
+	modTimes := make([]time.Time, 0, 3)
+
+	for _, name := range []string{r.certFile, r.keyFile, r.caFile} {
+		var modTime time.Time
+
+		if len(name) != 0 {
+			if info, err := os.Stat(name); err == nil {
+				modTime = info.ModTime()
+			}
+		}
+
+		modTimes = append(modTimes, modTime)
+	}
+
+	return modTimes
+}
+
+func equalTimes(a []time.Time, b []time.Time) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if !a[i].Equal(b[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func parseTLSVersion(version string) (uint16, error) {
+	switch version {
+	case "1.0":
+		return tls.VersionTLS10, nil
+	case "1.1":
+		return tls.VersionTLS11, nil
+	case "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	}
+
+	return 0, fmt.Errorf("invalid tls version: %q", version)
+}
+
+// parseCipherSuites parses the comma separated names of cipher suites like
+// "TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256", nil means the default ones.
+// The cipher suites of TLS 1.3 are not configurable.
+func parseCipherSuites(text string) ([]uint16, error) {
+	var ids []uint16
+
+	for _, name := range strings.Split(text, ",") {
+		if name = strings.TrimSpace(name); len(name) == 0 {
+			continue
+		}
+
+		id, ok := cipherSuiteID(name)
+		if !ok {
+			return nil, fmt.Errorf("invalid cipher suite: %q", name)
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+func cipherSuiteID(name string) (uint16, bool) {
+	for _, suite := range tls.CipherSuites() {
+		if suite.Name == name {
+			return suite.ID, true
+		}
+	}
+
+	return 0, false
+}
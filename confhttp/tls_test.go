@@ -0,0 +1,176 @@
+package confhttp_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rbee3u/gohelp/confhttp"
+)
+
+type certKey struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newCert(t *testing.T, name string, usage x509.ExtKeyUsage, parent *certKey) *certKey {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	serial, _ := rand.Int(rand.Reader, big.NewInt(1<<62))
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+
+	signer, signerKey := template, key
+	if parent == nil {
+		template.IsCA, template.BasicConstraintsValid = true, true
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+
+	cert, _ := x509.ParseCertificate(der)
+
+	return &certKey{cert: cert, key: key}
+}
+
+func (ck *certKey) write(t *testing.T, certFile string, keyFile string) {
+	t.Helper()
+
+	der, err := x509.MarshalECPrivateKey(ck.key)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ck.cert.Raw}), 0o600); err != nil {
+		t.Fatalf("failed to write cert: %v", err)
+	}
+
+	if len(keyFile) == 0 {
+		return
+	}
+
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatalf("failed to write key: %v", err)
+	}
+}
+
+func (ck *certKey) tlsCertificate() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{ck.cert.Raw}, PrivateKey: ck.key}
+}
+
+func TestServerTLS(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile, caFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key"), filepath.Join(dir, "ca.crt")
+
+	ca := newCert(t, "ca", x509.ExtKeyUsageAny, nil)
+	ca.write(t, caFile, "")
+	newCert(t, "first", x509.ExtKeyUsageServerAuth, ca).write(t, certFile, keyFile)
+
+	s, err := confhttp.New(
+		confhttp.WithTLSCert(certFile),
+		confhttp.WithTLSKey(keyFile),
+		confhttp.WithTLSClientCA(caFile),
+		confhttp.WithTLSMinVersion("1.3"),
+		confhttp.WithTLSReloadInterval("1ms"),
+	)
+	if err != nil {
+		t.Fatalf("failed to new: %v", err)
+	}
+
+	s.Kernel().GET("/", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	srv := &http.Server{Handler: s.Kernel(), ReadHeaderTimeout: time.Second}
+	defer srv.Close()
+
+	go func() { _ = srv.Serve(tls.NewListener(ln, s.TLSConfig())) }()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+
+	get := func(certs ...tls.Certificate) (string, error) {
+		client := &http.Client{Transport: &http.Transport{
+			TLSClientConfig:   &tls.Config{RootCAs: roots, ServerName: "localhost", Certificates: certs, MinVersion: tls.VersionTLS12},
+			DisableKeepAlives: true,
+		}}
+
+		resp, err := client.Get("https://" + ln.Addr().String())
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+
+		return resp.TLS.PeerCertificates[0].Subject.CommonName, nil
+	}
+
+	client := newCert(t, "client", x509.ExtKeyUsageClientAuth, ca).tlsCertificate()
+
+	if got, err := get(client); err != nil || got != "first" {
+		t.Errorf("got: %q, %v, want: %q", got, err, "first")
+	}
+
+	if _, err := get(); err == nil {
+		t.Errorf("got: %v, want: error without client certificate", err)
+	}
+
+	stranger := newCert(t, "stranger", x509.ExtKeyUsageAny, nil)
+	if _, err := get(newCert(t, "client", x509.ExtKeyUsageClientAuth, stranger).tlsCertificate()); err == nil {
+		t.Errorf("got: %v, want: error with unknown client certificate", err)
+	}
+
+	newCert(t, "second", x509.ExtKeyUsageServerAuth, ca).write(t, certFile, keyFile)
+
+	future := time.Now().Add(time.Minute)
+	_ = os.Chtimes(certFile, future, future)
+	_ = os.Chtimes(keyFile, future, future)
+
+	time.Sleep(10 * time.Millisecond)
+
+	if got, err := get(client); err != nil || got != "second" {
+		t.Errorf("got: %q, %v, want: %q", got, err, "second")
+	}
+}
+
+func TestServerTLSInvalid(t *testing.T) {
+	for _, opts := range [][]confhttp.ServerOption{
+		{confhttp.WithTLSCert("missing.crt"), confhttp.WithTLSKey("missing.key")},
+		{confhttp.WithTLSCert("missing.crt"), confhttp.WithTLSMinVersion("2.0")},
+		{confhttp.WithTLSCert("missing.crt"), confhttp.WithTLSCipherSuites("TLS_UNKNOWN")},
+	} {
+		if _, err := confhttp.New(opts...); err == nil {
+			t.Errorf("got: %v, want: error", err)
+		}
+	}
+}
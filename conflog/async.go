@@ -0,0 +1,219 @@
+package conflog
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DropPolicyBlock blocks the caller until there is room in the buffer.
+	DropPolicyBlock = "block"
+	// DropPolicyDropNewest drops the entry being written if the buffer is full.
+	DropPolicyDropNewest = "drop-newest"
+	// DropPolicyDropDebug drops the oldest buffered debug or trace entry to make
+	// room if the buffer is full, and falls back to DropPolicyDropNewest.
+	DropPolicyDropDebug = "drop-debug"
+)
+
+// LevelWriter is implemented by writers which care about the level of the
+// entries they write.
+type LevelWriter interface {
+	WriteLevel(level logrus.Level, p []byte) (int, error)
+}
+
+type record struct {
+	level logrus.Level
+	data  []byte
+}
+
+// AsyncWriter writes to out in a background goroutine through a bounded
+// buffer, so that a slow out does not block the callers. Fatal and panic
+// entries are never dropped, and they are written before WriteLevel returns,
+// since the process is about to exit or panic.
+type AsyncWriter struct {
+	out     io.Writer
+	policy  string
+	dropped uint64
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	writing bool
+	closed  bool
+	done    chan struct{}
+
+	// records is a ring buffer of count records starting at head.
+	records []record
+	head    int
+	count   int
+}
+
+// NewAsyncWriter starts an AsyncWriter which buffers at most size entries,
+// policy decides what to do when the buffer is full.
+func NewAsyncWriter(out io.Writer, size int, policy string) (*AsyncWriter, error) {
+	switch policy {
+	case DropPolicyBlock, DropPolicyDropNewest, DropPolicyDropDebug:
+	default:
+		return nil, fmt.Errorf("invalid drop policy: %q", policy)
+	}
+
+	if size <= 0 {
+		return nil, fmt.Errorf("invalid size: %d <= 0", size)
+	}
+
+	w := &AsyncWriter{
+		out:     out,
+		policy:  policy,
+		records: make([]record, size),
+		done:    make(chan struct{}),
+	}
+	w.cond = sync.NewCond(&w.mu)
+
+	go w.run()
+
+	return w, nil
+}
+
+func (w *AsyncWriter) Write(p []byte) (int, error) {
+	return w.WriteLevel(logrus.InfoLevel, p)
+}
+
+func (w *AsyncWriter) WriteLevel(level logrus.Level, p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	urgent := level <= logrus.FatalLevel
+
+	for !w.closed && w.count == len(w.records) {
+		if w.policy == DropPolicyBlock || urgent {
+			w.cond.Wait()
+
+			continue
+		}
+
+		if w.policy == DropPolicyDropDebug && w.dropDebug(level) {
+			break
+		}
+
+		atomic.AddUint64(&w.dropped, 1)
+
+		return len(p), nil
+	}
+
+	if w.closed {
+		return 0, io.ErrClosedPipe
+	}
+
+	// The p may be reused by the caller once Write returns.
+	w.records[(w.head+w.count)%len(w.records)] = record{level: level, data: append([]byte(nil), p...)}
+	w.count++
+	w.cond.Broadcast()
+
+	// The entries buffered before are drained too, which keeps the order.
+	for urgent && (w.count != 0 || w.writing) {
+		w.cond.Wait()
+	}
+
+	return len(p), nil
+}
+
+// dropDebug drops the oldest buffered debug or trace entry, it reports
+// whether there is room for an entry of level after that. Only the entries
+// older than the dropped one are shifted, towards the new head.
+func (w *AsyncWriter) dropDebug(level logrus.Level) bool {
+	size := len(w.records)
+
+	for i := 0; i < w.count; i++ {
+		if w.records[(w.head+i)%size].level < logrus.DebugLevel {
+			continue
+		}
+
+		for j := i; j > 0; j-- {
+			w.records[(w.head+j)%size] = w.records[(w.head+j-1)%size]
+		}
+
+		w.records[w.head] = record{}
+		w.head = (w.head + 1) % size
+		w.count--
+		atomic.AddUint64(&w.dropped, 1)
+
+		return true
+	}
+
+	return false
+}
+
+// Dropped returns the number of entries dropped so far.
+func (w *AsyncWriter) Dropped() uint64 {
+	return atomic.LoadUint64(&w.dropped)
+}
+
+// Flush waits until all buffered entries have been written.
+func (w *AsyncWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for w.count != 0 || w.writing {
+		w.cond.Wait()
+	}
+
+	return nil
+}
+
+// Close drains the buffer and stops the background goroutine, entries
+// written after Close are rejected with io.ErrClosedPipe.
+func (w *AsyncWriter) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+
+		return nil
+	}
+
+	w.closed = true
+	w.cond.Broadcast()
+	w.mu.Unlock()
+
+	<-w.done
+
+	return nil
+}
+
+func (w *AsyncWriter) run() {
+	defer close(w.done)
+
+	for {
+		w.mu.Lock()
+		for w.count == 0 && !w.closed {
+			w.cond.Wait()
+		}
+
+		if w.count == 0 {
+			w.mu.Unlock()
+
+			return
+		}
+
+		r := w.records[w.head]
+		w.records[w.head] = record{}
+		w.head = (w.head + 1) % len(w.records)
+		w.count--
+		w.writing = true
+		w.cond.Broadcast()
+		w.mu.Unlock()
+
+		if lw, ok := w.out.(LevelWriter); ok {
+			_, _ = lw.WriteLevel(r.level, r.data)
+		} else {
+			_, _ = w.out.Write(r.data)
+		}
+
+		w.mu.Lock()
+		w.writing = false
+		w.cond.Broadcast()
+		w.mu.Unlock()
+	}
+}
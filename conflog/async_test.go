@@ -0,0 +1,153 @@
+package conflog_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/rbee3u/gohelp/conflog"
+	"github.com/sirupsen/logrus"
+)
+
+// gateWriter signals entered on every Write, and blocks until gate is closed.
+type gateWriter struct {
+	entered chan struct{}
+	gate    chan struct{}
+	mu      sync.Mutex
+	buf     bytes.Buffer
+}
+
+func (w *gateWriter) Write(p []byte) (int, error) {
+	select {
+	case w.entered <- struct{}{}:
+	default:
+	}
+
+	<-w.gate
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.buf.Write(p)
+}
+
+func TestAsyncWriterDropDebug(t *testing.T) {
+	out := &gateWriter{entered: make(chan struct{}, 1), gate: make(chan struct{})}
+
+	w, err := conflog.NewAsyncWriter(out, 2, conflog.DropPolicyDropDebug)
+	if err != nil {
+		t.Fatalf("failed to new async writer: %v", err)
+	}
+
+	_, _ = w.WriteLevel(logrus.InfoLevel, []byte("a\n"))
+	<-out.entered
+
+	_, _ = w.WriteLevel(logrus.DebugLevel, []byte("b\n"))
+	_, _ = w.WriteLevel(logrus.InfoLevel, []byte("c\n"))
+	_, _ = w.WriteLevel(logrus.InfoLevel, []byte("d\n"))
+	_, _ = w.WriteLevel(logrus.DebugLevel, []byte("e\n"))
+
+	if got, want := w.Dropped(), uint64(2); got != want {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+
+	close(out.gate)
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("failed to close: %v", err)
+	}
+
+	if got, want := out.buf.String(), "a\nc\nd\n"; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+
+	if _, err := w.Write([]byte("f\n")); err == nil {
+		t.Errorf("expect err not to be nil")
+	}
+}
+
+func TestAsyncWriterDropNewest(t *testing.T) {
+	out := &gateWriter{entered: make(chan struct{}, 1), gate: make(chan struct{})}
+
+	w, err := conflog.NewAsyncWriter(out, 1, conflog.DropPolicyDropNewest)
+	if err != nil {
+		t.Fatalf("failed to new async writer: %v", err)
+	}
+
+	_, _ = w.Write([]byte("a\n"))
+	<-out.entered
+
+	_, _ = w.Write([]byte("b\n"))
+	_, _ = w.Write([]byte("c\n"))
+
+	close(out.gate)
+
+	if err := w.Flush(); err != nil {
+		t.Fatalf("failed to flush: %v", err)
+	}
+
+	if got, want := out.buf.String(), "a\nb\n"; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+
+	if got, want := w.Dropped(), uint64(1); got != want {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+
+	_ = w.Close()
+}
+
+func TestLoggerAsyncFatal(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "app.log")
+
+	l, err := conflog.New(conflog.WithFile(name), conflog.WithAsync("enable"))
+	if err != nil {
+		t.Fatalf("failed to new: %v", err)
+	}
+	defer l.Close()
+
+	var content []byte
+
+	l.ExitFunc = func(int) {
+		content, _ = os.ReadFile(name)
+	}
+
+	l.Info("before")
+	l.Fatal("boom")
+
+	if !bytes.Contains(content, []byte("before")) || !bytes.Contains(content, []byte("boom")) {
+		t.Errorf("unexpected content at exit: %q", content)
+	}
+}
+
+func TestLoggerAsync(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "app.log")
+
+	l, err := conflog.New(conflog.WithFile(name), conflog.WithFormat("json"), conflog.WithAsync("enable"))
+	if err != nil {
+		t.Fatalf("failed to new: %v", err)
+	}
+
+	for i := 0; i < 100; i++ {
+		l.WithField("i", i).Info("hello")
+	}
+
+	if err := l.Close(); err != nil {
+		t.Fatalf("failed to close: %v", err)
+	}
+
+	content, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatalf("failed to read file: %v", err)
+	}
+
+	if got, want := bytes.Count(content, []byte("\n")), 100; got != want {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+
+	if _, err := conflog.New(conflog.WithAsync("enable"), conflog.WithAsyncDropPolicy("x")); err == nil {
+		t.Errorf("expect err not to be nil")
+	}
+}
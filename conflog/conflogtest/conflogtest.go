@@ -0,0 +1,191 @@
+// Package conflogtest provides a Logger backed by an in-memory sink, and
+// helpers to assert on what has been logged in tests of any package.
+package conflogtest
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rbee3u/gohelp/conflog"
+	"github.com/sirupsen/logrus"
+)
+
+// Entry is a recorded entry, Fields includes those added by the hooks of
+// conflog, such as the context fields and the error chains.
+type Entry struct {
+	Time    time.Time
+	Level   logrus.Level
+	Message string
+	Fields  logrus.Fields
+}
+
+// Recorder is a logrus.Hook which records all the entries it fires on.
+type Recorder struct {
+	mu      sync.Mutex
+	entries []Entry
+}
+
+// New creates a Logger whose entries are recorded by the returned Recorder
+// instead of written anywhere. The level is trace unless overridden by opts,
+// and the Logger is closed when the test finishes.
+func New(t testing.TB, opts ...conflog.Option) (*conflog.Logger, *Recorder) {
+	t.Helper()
+
+	l, err := conflog.New(append([]conflog.Option{conflog.WithLevel(logrus.TraceLevel.String())}, opts...)...)
+	if err != nil {
+		t.Fatalf("failed to new logger: %v", err)
+	}
+
+	t.Cleanup(func() {
+		if err := l.Close(); err != nil {
+			t.Errorf("failed to close logger: %v", err)
+		}
+	})
+
+	l.SetOutput(io.Discard)
+
+	r := &Recorder{}
+	l.AddHook(r)
+
+	return l, r
+}
+
+func (r *Recorder) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (r *Recorder) Fire(entry *logrus.Entry) error {
+	fields := make(logrus.Fields, len(entry.Data))
+	for k, v := range entry.Data {
+		fields[k] = v
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.entries = append(r.entries, Entry{Time: entry.Time, Level: entry.Level, Message: entry.Message, Fields: fields})
+
+	return nil
+}
+
+// Entries returns a copy of the recorded entries in order.
+func (r *Recorder) Entries() []Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]Entry(nil), r.entries...)
+}
+
+// Reset forgets all the recorded entries.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.entries = nil
+}
+
+// Count returns the number of recorded entries of level.
+func (r *Recorder) Count(level logrus.Level) int {
+	count := 0
+
+	for _, entry := range r.Entries() {
+		if entry.Level == level {
+			count++
+		}
+	}
+
+	return count
+}
+
+// Find returns the recorded entries whose message contains substr.
+func (r *Recorder) Find(substr string) []Entry {
+	var found []Entry
+
+	for _, entry := range r.Entries() {
+		if strings.Contains(entry.Message, substr) {
+			found = append(found, entry)
+		}
+	}
+
+	return found
+}
+
+// HasField reports whether any recorded entry has the field key equal to
+// value, errors and stringers are compared by their strings if value is a
+// string.
+func (r *Recorder) HasField(key string, value interface{}) bool {
+	for _, entry := range r.Entries() {
+		if v, ok := entry.Fields[key]; ok && fieldEqual(v, value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AssertContains reports an error if no recorded message contains substr.
+func (r *Recorder) AssertContains(t testing.TB, substr string) {
+	t.Helper()
+
+	if len(r.Find(substr)) == 0 {
+		t.Errorf("no entry contains message: %q, entries: %s", substr, r)
+	}
+}
+
+// AssertNotContains reports an error if any recorded message contains substr.
+func (r *Recorder) AssertNotContains(t testing.TB, substr string) {
+	t.Helper()
+
+	if found := r.Find(substr); len(found) != 0 {
+		t.Errorf("%d entries contain message: %q, entries: %s", len(found), substr, r)
+	}
+}
+
+// AssertField reports an error if no recorded entry has the field key equal
+// to value.
+func (r *Recorder) AssertField(t testing.TB, key string, value interface{}) {
+	t.Helper()
+
+	if !r.HasField(key, value) {
+		t.Errorf("no entry has field: %s=%v, entries: %s", key, value, r)
+	}
+}
+
+// AssertCount reports an error if the number of recorded entries of level
+// is not want.
+func (r *Recorder) AssertCount(t testing.TB, level logrus.Level, want int) {
+	t.Helper()
+
+	if got := r.Count(level); got != want {
+		t.Errorf("got: %v, want: %v %s entries", got, want, level)
+	}
+}
+
+// String formats the recorded entries one per line, for failure messages.
+func (r *Recorder) String() string {
+	var b strings.Builder
+
+	for _, entry := range r.Entries() {
+		_, _ = fmt.Fprintf(&b, "\n\t%s %q %v", entry.Level, entry.Message, entry.Fields)
+	}
+
+	return b.String()
+}
+
+func fieldEqual(got interface{}, want interface{}) bool {
+	if s, ok := want.(string); ok {
+		switch v := got.(type) {
+		case error:
+			return v.Error() == s
+		case fmt.Stringer:
+			return v.String() == s
+		}
+	}
+
+	return reflect.DeepEqual(got, want)
+}
@@ -0,0 +1,49 @@
+package conflogtest_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/rbee3u/gohelp/conflog"
+	"github.com/rbee3u/gohelp/conflog/conflogtest"
+	"github.com/sirupsen/logrus"
+)
+
+func TestRecorder(t *testing.T) {
+	l, r := conflogtest.New(t)
+
+	l.Debug("starting")
+	l.WithField("port", 8080).Info("listening")
+	l.WithError(errors.New("boom")).Error("failed")
+
+	if got, want := len(r.Entries()), 3; got != want {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+
+	r.AssertContains(t, "listen")
+	r.AssertNotContains(t, "stopped")
+	r.AssertField(t, "port", 8080)
+	r.AssertField(t, logrus.ErrorKey, "boom")
+	r.AssertCount(t, logrus.DebugLevel, 1)
+	r.AssertCount(t, logrus.ErrorLevel, 1)
+
+	if r.HasField("port", "8080") {
+		t.Errorf("got: %v, want: %v", true, false)
+	}
+
+	r.Reset()
+
+	if got, want := len(r.Entries()), 0; got != want {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+}
+
+func TestRecorderLevel(t *testing.T) {
+	l, r := conflogtest.New(t, conflog.WithLevel("warn"))
+
+	l.Info("ignored")
+	l.Warn("kept")
+
+	r.AssertNotContains(t, "ignored")
+	r.AssertContains(t, "kept")
+}
@@ -0,0 +1,88 @@
+package conflog
+
+import (
+	"context"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+type entryKey struct{}
+
+// IntoContext returns a copy of ctx which carries entry.
+func IntoContext(ctx context.Context, entry *logrus.Entry) context.Context {
+	return context.WithValue(ctx, entryKey{}, entry)
+}
+
+// FromContext returns the entry carried by ctx, or an entry of the standard
+// logger if there is none, in both cases the entry is bound to ctx.
+func FromContext(ctx context.Context) *logrus.Entry {
+	if entry, ok := ctx.Value(entryKey{}).(*logrus.Entry); ok {
+		return entry.WithContext(ctx)
+	}
+
+	return logrus.NewEntry(logrus.StandardLogger()).WithContext(ctx)
+}
+
+// WithContext returns the entry carried by ctx if there is one, otherwise
+// returns a new entry of l, in both cases the entry is bound to ctx.
+func (l *Logger) WithContext(ctx context.Context) *logrus.Entry {
+	if entry, ok := ctx.Value(entryKey{}).(*logrus.Entry); ok {
+		return entry.WithContext(ctx)
+	}
+
+	return l.Logger.WithContext(ctx)
+}
+
+// AddContextField registers key so that the value of key in the context of
+// an entry is added to the entry as field, unless the field is already set.
+func (l *Logger) AddContextField(field string, key interface{}) {
+	if l.contextHook == nil {
+		l.contextHook = &ContextHook{}
+	}
+
+	l.contextHook.Register(field, key)
+}
+
+// ContextHook is a logrus.Hook which extracts registered context keys into
+// fields of entries.
+type ContextHook struct {
+	mu     sync.RWMutex
+	fields map[string]interface{}
+}
+
+func (h *ContextHook) Register(field string, key interface{}) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.fields == nil {
+		h.fields = map[string]interface{}{}
+	}
+
+	h.fields[field] = key
+}
+
+func (h *ContextHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *ContextHook) Fire(entry *logrus.Entry) error {
+	if entry.Context == nil {
+		return nil
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for field, key := range h.fields {
+		if _, ok := entry.Data[field]; ok {
+			continue
+		}
+
+		if value := entry.Context.Value(key); value != nil {
+			entry.Data[field] = value
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package conflog_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/rbee3u/gohelp/conflog"
+)
+
+type userKey struct{}
+
+func TestContext(t *testing.T) {
+	l, err := conflog.New(conflog.WithFormat("json"))
+	if err != nil {
+		t.Fatalf("failed to new: %v", err)
+	}
+
+	buf := new(bytes.Buffer)
+	l.SetOutput(buf)
+	l.AddContextField("user", userKey{})
+
+	ctx := context.WithValue(context.Background(), userKey{}, "alice")
+	ctx = conflog.IntoContext(ctx, l.WithField("request_id", "r1"))
+
+	conflog.FromContext(ctx).Info("hello")
+	l.WithContext(ctx).WithField("user", "bob").Info("world")
+	l.WithContext(context.Background()).Info("plain")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("unexpected lines: %q", lines)
+	}
+
+	for _, tt := range []struct {
+		line string
+		want string
+	}{
+		{lines[0], `"request_id":"r1"`},
+		{lines[0], `"user":"alice"`},
+		{lines[1], `"request_id":"r1"`},
+		{lines[1], `"user":"bob"`},
+	} {
+		if !strings.Contains(tt.line, tt.want) {
+			t.Errorf("expect %q to contain %q", tt.line, tt.want)
+		}
+	}
+
+	if strings.Contains(lines[2], "request_id") || strings.Contains(lines[2], "user") {
+		t.Errorf("expect %q not to contain request fields", lines[2])
+	}
+}
@@ -0,0 +1,85 @@
+package conflog
+
+import (
+	"strings"
+
+	"github.com/rbee3u/gohelp/epkg"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	ErrorChainField     = "error_chain"
+	ErrorLocationsField = "error_locations"
+	ErrorCodeField      = "error_code"
+	ErrorStackField     = "error_stack"
+)
+
+// StringList is a list of strings which is rendered as an array in JSON,
+// and as a string joined by " | " in text.
+type StringList []string
+
+func (sl StringList) String() string {
+	return strings.Join(sl, " | ")
+}
+
+// ErrorHook is a logrus.Hook which renders the error set by WithError into
+// structured fields, i.e. the messages of its chain, the locations recorded
+// by epkg, the status code and the stack recorded by epkg. Plain errors with
+// nothing but a single message are left as they are.
+type ErrorHook struct{}
+
+func (h *ErrorHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *ErrorHook) Fire(entry *logrus.Entry) error {
+	err, ok := entry.Data[logrus.ErrorKey].(error)
+	if !ok || err == nil {
+		return nil
+	}
+
+	links := epkg.Chain(err)
+	stack := epkg.StackTrace(err)
+
+	var (
+		messages  = make(StringList, 0, len(links))
+		locations = make(StringList, 0, len(links))
+		located   bool
+		code      int
+	)
+
+	for _, link := range links {
+		messages = append(messages, link.Message)
+
+		location := link.Location
+		if len(location) == 0 {
+			location = "-"
+		} else {
+			located = true
+		}
+
+		locations = append(locations, location)
+
+		if code == 0 {
+			code = link.Code
+		}
+	}
+
+	if len(messages) > 1 || located || code != 0 || len(stack) != 0 {
+		entry.Data[ErrorChainField] = messages
+	}
+
+	if located {
+		entry.Data[ErrorLocationsField] = locations
+	}
+
+	if code != 0 {
+		entry.Data[ErrorCodeField] = code
+	}
+
+	if len(stack) != 0 {
+		entry.Data[ErrorStackField] = StringList(stack)
+	}
+
+	return nil
+}
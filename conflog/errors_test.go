@@ -0,0 +1,74 @@
+package conflog_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rbee3u/gohelp/confhttp/status"
+	"github.com/rbee3u/gohelp/conflog"
+	"github.com/rbee3u/gohelp/epkg"
+)
+
+func TestErrorHook(t *testing.T) {
+	l, err := conflog.New(conflog.WithFormat("json"))
+	if err != nil {
+		t.Fatalf("failed to new: %v", err)
+	}
+
+	buf := new(bytes.Buffer)
+	l.SetOutput(buf)
+
+	cause := epkg.ErrorWL("no rows")
+	l.WithError(status.WrapWL(cause, http.StatusNotFound, "user not found")).Error("failed")
+
+	var got struct {
+		Error     string   `json:"error"`
+		Chain     []string `json:"error_chain"`
+		Locations []string `json:"error_locations"`
+		Code      int      `json:"error_code"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("failed to unmarshal %q: %v", buf, err)
+	}
+
+	if want := "user not found: no rows"; got.Error != want {
+		t.Errorf("got: %q, want: %q", got.Error, want)
+	}
+
+	if want := []string{"user not found", "no rows"}; !reflect.DeepEqual(got.Chain, want) {
+		t.Errorf("got: %q, want: %q", got.Chain, want)
+	}
+
+	if len(got.Locations) != 2 || !strings.Contains(got.Locations[0], "TestErrorHook") {
+		t.Errorf("unexpected locations: %q", got.Locations)
+	}
+
+	if got.Code != http.StatusNotFound {
+		t.Errorf("got: %v, want: %v", got.Code, http.StatusNotFound)
+	}
+
+	text, err := conflog.New()
+	if err != nil {
+		t.Fatalf("failed to new: %v", err)
+	}
+
+	buf.Reset()
+	text.SetOutput(buf)
+	text.WithError(epkg.Stack(epkg.Wrap(errors.New("b"), "a"))).Error("failed")
+
+	if line := buf.String(); !strings.Contains(line, `error_chain="a | b"`) || !strings.Contains(line, "error_stack=") {
+		t.Errorf("unexpected line: %q", line)
+	}
+
+	buf.Reset()
+	text.WithError(errors.New("plain")).Error("failed")
+
+	if line := buf.String(); strings.Contains(line, "error_chain") {
+		t.Errorf("unexpected line: %q", line)
+	}
+}
@@ -0,0 +1,258 @@
+package conflog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type traceKey struct{}
+
+type traceIDs struct {
+	traceID string
+	spanID  string
+}
+
+// TraceExtractor extracts the trace id and span id from ctx, empty strings
+// are returned if there is no trace in ctx.
+type TraceExtractor func(ctx context.Context) (traceID string, spanID string)
+
+// WithTrace returns a copy of ctx which carries the trace id and span id,
+// they are extracted by TraceFromContext, which is the default extractor.
+func WithTrace(ctx context.Context, traceID, spanID string) context.Context {
+	return context.WithValue(ctx, traceKey{}, traceIDs{traceID: traceID, spanID: spanID})
+}
+
+// TraceFromContext extracts the trace id and span id carried by ctx.
+func TraceFromContext(ctx context.Context) (string, string) {
+	ids, _ := ctx.Value(traceKey{}).(traceIDs)
+
+	return ids.traceID, ids.spanID
+}
+
+// SetTraceExtractor replaces the default extractor of trace id and span id,
+// it must be called before logging, e.g. to adapt the OpenTelemetry SDK:
+//
+//	l.SetTraceExtractor(func(ctx context.Context) (string, string) {
+//		sc := trace.SpanContextFromContext(ctx)
+//		return sc.TraceID().String(), sc.SpanID().String()
+//	})
+func (l *Logger) SetTraceExtractor(extractor TraceExtractor) {
+	l.traceExtractor = extractor
+}
+
+func (l *Logger) extractTrace(ctx context.Context) (string, string) {
+	if l.traceExtractor != nil {
+		return l.traceExtractor(ctx)
+	}
+
+	return TraceFromContext(ctx)
+}
+
+func extractTrace(extractor TraceExtractor, entry *logrus.Entry) (string, string) {
+	if entry.Context == nil {
+		return "", ""
+	}
+
+	if extractor == nil {
+		extractor = TraceFromContext
+	}
+
+	traceID, spanID := extractor(entry.Context)
+
+	// The all-zero ids are invalid in both W3C and OpenTelemetry.
+	if strings.Trim(traceID, "0") == "" {
+		traceID = ""
+	}
+
+	if strings.Trim(spanID, "0") == "" {
+		spanID = ""
+	}
+
+	return traceID, spanID
+}
+
+// OTelFormatter formats entries as JSON following the OpenTelemetry log data
+// model, the fields of entries are put into attributes.
+type OTelFormatter struct {
+	TraceExtractor TraceExtractor
+}
+
+func (f *OTelFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	number, text := otelSeverity(entry.Level)
+
+	record := map[string]interface{}{
+		"timestamp":       entry.Time.UTC().Format(time.RFC3339Nano),
+		"severity_number": number,
+		"severity_text":   text,
+		"body":            entry.Message,
+	}
+
+	attributes := fieldsOf(entry)
+
+	if entry.HasCaller() {
+		attributes["code.function"] = entry.Caller.Function
+		attributes["code.filepath"] = entry.Caller.File
+		attributes["code.lineno"] = entry.Caller.Line
+	}
+
+	if len(attributes) != 0 {
+		record["attributes"] = attributes
+	}
+
+	if traceID, spanID := extractTrace(f.TraceExtractor, entry); len(traceID) != 0 {
+		record["trace_id"] = traceID
+
+		if len(spanID) != 0 {
+			record["span_id"] = spanID
+		}
+	}
+
+	return marshalRecord(entry, record)
+}
+
+func otelSeverity(level logrus.Level) (int, string) {
+	switch level {
+	case logrus.TraceLevel:
+		return 1, "TRACE"
+	case logrus.DebugLevel:
+		return 5, "DEBUG"
+	case logrus.InfoLevel:
+		return 9, "INFO"
+	case logrus.WarnLevel:
+		return 13, "WARN"
+	case logrus.ErrorLevel:
+		return 17, "ERROR"
+	case logrus.FatalLevel:
+		return 21, "FATAL"
+	case logrus.PanicLevel:
+		return 24, "FATAL4"
+	}
+
+	return 0, "UNSPECIFIED"
+}
+
+// ECSFormatter formats entries as JSON following the Elastic Common Schema,
+// the fields of entries are put at the top level.
+type ECSFormatter struct {
+	TraceExtractor TraceExtractor
+}
+
+const ecsVersion = "1.12.0"
+
+func (f *ECSFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	record := fieldsOf(entry)
+	record["@timestamp"] = entry.Time.UTC().Format(time.RFC3339Nano)
+	record["log.level"] = entry.Level.String()
+	record["message"] = entry.Message
+	record["ecs.version"] = ecsVersion
+
+	if entry.HasCaller() {
+		record["log.origin.function"] = entry.Caller.Function
+		record["log.origin.file.name"] = entry.Caller.File
+		record["log.origin.file.line"] = entry.Caller.Line
+	}
+
+	if traceID, spanID := extractTrace(f.TraceExtractor, entry); len(traceID) != 0 {
+		record["trace.id"] = traceID
+
+		if len(spanID) != 0 {
+			record["span.id"] = spanID
+		}
+	}
+
+	return marshalRecord(entry, record)
+}
+
+// GCPFormatter formats entries as JSON following the structured logging of
+// Google Cloud Logging, the fields of entries are put at the top level.
+// The trace is qualified with ProjectID if it is not empty.
+type GCPFormatter struct {
+	ProjectID      string
+	TraceExtractor TraceExtractor
+}
+
+func (f *GCPFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	record := fieldsOf(entry)
+	record["time"] = entry.Time.UTC().Format(time.RFC3339Nano)
+	record["severity"] = gcpSeverity(entry.Level)
+	record["message"] = entry.Message
+
+	if entry.HasCaller() {
+		record["logging.googleapis.com/sourceLocation"] = map[string]interface{}{
+			"file":     entry.Caller.File,
+			"line":     fmt.Sprint(entry.Caller.Line),
+			"function": entry.Caller.Function,
+		}
+	}
+
+	if traceID, spanID := extractTrace(f.TraceExtractor, entry); len(traceID) != 0 {
+		if len(f.ProjectID) != 0 {
+			traceID = "projects/" + f.ProjectID + "/traces/" + traceID
+		}
+
+		record["logging.googleapis.com/trace"] = traceID
+
+		if len(spanID) != 0 {
+			record["logging.googleapis.com/spanId"] = spanID
+		}
+	}
+
+	return marshalRecord(entry, record)
+}
+
+func gcpSeverity(level logrus.Level) string {
+	switch level {
+	case logrus.TraceLevel, logrus.DebugLevel:
+		return "DEBUG"
+	case logrus.InfoLevel:
+		return "INFO"
+	case logrus.WarnLevel:
+		return "WARNING"
+	case logrus.ErrorLevel:
+		return "ERROR"
+	case logrus.FatalLevel:
+		return "CRITICAL"
+	case logrus.PanicLevel:
+		return "ALERT"
+	}
+
+	return "DEFAULT"
+}
+
+// fieldsOf copies the fields of entry, errors are converted to their
+// messages since most of them can not be marshaled as JSON.
+func fieldsOf(entry *logrus.Entry) map[string]interface{} {
+	fields := make(map[string]interface{}, len(entry.Data)+8)
+
+	for k, v := range entry.Data {
+		if err, ok := v.(error); ok {
+			v = err.Error()
+		}
+
+		fields[k] = v
+	}
+
+	return fields
+}
+
+func marshalRecord(entry *logrus.Entry, record map[string]interface{}) ([]byte, error) {
+	buf := entry.Buffer
+	if buf == nil {
+		buf = new(bytes.Buffer)
+	}
+
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+
+	if err := encoder.Encode(record); err != nil {
+		return nil, fmt.Errorf("failed to marshal record: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
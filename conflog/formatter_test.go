@@ -0,0 +1,107 @@
+package conflog_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/rbee3u/gohelp/conflog"
+)
+
+func TestStructuredFormats(t *testing.T) {
+	ctx := conflog.WithTrace(context.Background(), "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7")
+
+	tests := []struct {
+		format string
+		want   map[string]interface{}
+	}{
+		{"otel", map[string]interface{}{
+			"severity_number": 13.0,
+			"severity_text":   "WARN",
+			"body":            "hello",
+			"attributes":      map[string]interface{}{"user": "alice", "error": "oops"},
+			"trace_id":        "4bf92f3577b34da6a3ce929d0e0e4736",
+			"span_id":         "00f067aa0ba902b7",
+		}},
+		{"ecs", map[string]interface{}{
+			"log.level":   "warning",
+			"message":     "hello",
+			"user":        "alice",
+			"error":       "oops",
+			"ecs.version": "1.12.0",
+			"trace.id":    "4bf92f3577b34da6a3ce929d0e0e4736",
+			"span.id":     "00f067aa0ba902b7",
+		}},
+		{"gcp", map[string]interface{}{
+			"severity":                      "WARNING",
+			"message":                       "hello",
+			"user":                          "alice",
+			"error":                         "oops",
+			"logging.googleapis.com/trace":  "projects/demo/traces/4bf92f3577b34da6a3ce929d0e0e4736",
+			"logging.googleapis.com/spanId": "00f067aa0ba902b7",
+		}},
+	}
+
+	for _, tt := range tests {
+		l, err := conflog.New(conflog.WithFormat(tt.format), conflog.WithGCPProject("demo"))
+		if err != nil {
+			t.Fatalf("failed to new: %v", err)
+		}
+
+		buf := new(bytes.Buffer)
+		l.SetOutput(buf)
+		l.WithContext(ctx).WithField("user", "alice").WithError(errors.New("oops")).Warn("hello")
+
+		var got map[string]interface{}
+		if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+			t.Fatalf("failed to unmarshal %q: %v", buf, err)
+		}
+
+		for key, want := range tt.want {
+			if g, _ := json.Marshal(got[key]); string(g) != mustMarshal(t, want) {
+				t.Errorf("format: %s, key: %s, got: %s, want: %s", tt.format, key, g, mustMarshal(t, want))
+			}
+		}
+	}
+}
+
+func TestTraceExtractor(t *testing.T) {
+	l, err := conflog.New(conflog.WithFormat("otel"))
+	if err != nil {
+		t.Fatalf("failed to new: %v", err)
+	}
+
+	l.SetTraceExtractor(func(ctx context.Context) (string, string) {
+		return "0af7651916cd43dd8448eb211c80319c", "00000000000000000"
+	})
+
+	buf := new(bytes.Buffer)
+	l.SetOutput(buf)
+	l.WithContext(context.Background()).Info("hello")
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("failed to unmarshal %q: %v", buf, err)
+	}
+
+	if got["trace_id"] != "0af7651916cd43dd8448eb211c80319c" {
+		t.Errorf("unexpected trace_id: %v", got["trace_id"])
+	}
+
+	if _, ok := got["span_id"]; ok {
+		t.Errorf("expect invalid span_id to be omitted")
+	}
+}
+
+func mustMarshal(t *testing.T, v interface{}) string {
+	t.Helper()
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+
+	return string(data)
+}
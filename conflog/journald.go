@@ -0,0 +1,112 @@
+package conflog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+const DefaultJournaldSocket = "/run/systemd/journal/socket"
+
+// JournaldWriter is a LevelWriter which sends entries to journald through
+// its native protocol, with PRIORITY mapped from the level.
+type JournaldWriter struct {
+	socket     string
+	identifier string
+
+	mu   sync.Mutex
+	conn *net.UnixConn
+}
+
+// NewJournaldWriter creates a JournaldWriter which sends to socket, the
+// connection is made lazily.
+func NewJournaldWriter(socket string, identifier string) *JournaldWriter {
+	return &JournaldWriter{socket: socket, identifier: identifier}
+}
+
+func (w *JournaldWriter) Write(p []byte) (int, error) {
+	return w.WriteLevel(logrus.InfoLevel, p)
+}
+
+func (w *JournaldWriter) WriteLevel(level logrus.Level, p []byte) (int, error) {
+	buf := new(bytes.Buffer)
+	writeJournalField(buf, "PRIORITY", []byte(strconv.Itoa(syslogSeverity(level))))
+
+	if len(w.identifier) != 0 {
+		writeJournalField(buf, "SYSLOG_IDENTIFIER", []byte(w.identifier))
+	}
+
+	writeJournalField(buf, "MESSAGE", bytes.TrimRight(p, "\n"))
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	// Retry once with a new connection, since the old one may be broken.
+	for retry := 0; ; retry++ {
+		if w.conn == nil {
+			conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: w.socket, Net: "unixgram"})
+			if err != nil {
+				return 0, fmt.Errorf("failed to dial: %w", err)
+			}
+
+			w.conn = conn
+		}
+
+		_, err := w.conn.Write(buf.Bytes())
+		if err == nil {
+			return len(p), nil
+		}
+
+		_ = w.conn.Close()
+		w.conn = nil
+
+		if retry > 0 {
+			return 0, fmt.Errorf("failed to write: %w", err)
+		}
+	}
+}
+
+func (w *JournaldWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn == nil {
+		return nil
+	}
+
+	err := w.conn.Close()
+	w.conn = nil
+
+	if err != nil {
+		return fmt.Errorf("failed to close conn: %w", err)
+	}
+
+	return nil
+}
+
+// writeJournalField writes a field in the native protocol, values which
+// contain newlines are written in the binary safe form.
+func writeJournalField(buf *bytes.Buffer, key string, value []byte) {
+	buf.WriteString(key)
+
+	if bytes.IndexByte(value, '\n') < 0 {
+		buf.WriteByte('=')
+		buf.Write(value)
+		buf.WriteByte('\n')
+
+		return
+	}
+
+	var size [8]byte
+
+	binary.LittleEndian.PutUint64(size[:], uint64(len(value)))
+	buf.WriteByte('\n')
+	buf.Write(size[:])
+	buf.Write(value)
+	buf.WriteByte('\n')
+}
@@ -2,11 +2,15 @@ package conflog
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"runtime"
 	"strings"
+	"syscall"
+	"time"
 
-	"github.com/rifflock/lfshook"
 	"github.com/sirupsen/logrus"
 )
 
@@ -15,8 +19,70 @@ type Logger struct {
 	ErrFile        string `env:""`
 	File           string `env:""`
 	Format         string `env:""`
-	Level          string `env:""`
-	ReportCaller   string `env:""`
+	// Level is the global level optionally followed by the levels of named
+	// loggers, e.g. "info,db=debug,http=warn".
+	Level        string `env:""`
+	ReportCaller string `env:""`
+
+	// Outputs routes entries to several destinations, each with its own
+	// level range and format, e.g. JSON to a file and errors as text to
+	// stderr. The File is still the main output if it is not empty, and
+	// stderr is the main output only if neither of them is configured.
+	Outputs []Output `env:""`
+
+	// GCPProject qualifies the trace in "gcp" format.
+	GCPProject string `env:""`
+
+	// MaxSize is the size in megabytes at which File and ErrFile are rotated.
+	MaxSize int64 `env:""`
+	// RotateEvery is the interval at which File and ErrFile are rotated.
+	RotateEvery string `env:""`
+	// MaxBackups is the number of rotated backups to keep.
+	MaxBackups int `env:""`
+	// MaxAge is the maximum duration to keep rotated backups.
+	MaxAge   string `env:""`
+	Compress string `env:""`
+
+	// Async makes all the outputs written in background.
+	Async string `env:""`
+	// AsyncBuffer is the number of entries buffered in async mode.
+	AsyncBuffer int `env:""`
+	// AsyncDropPolicy is one of "block", "drop-newest" and "drop-debug".
+	AsyncDropPolicy string `env:""`
+
+	// SampleFirst is the number of entries with the same level and message
+	// allowed within every SampleTick, after which only every
+	// SampleThereafter-th of them is allowed.
+	SampleFirst      int    `env:""`
+	SampleThereafter int    `env:""`
+	SampleTick       string `env:""`
+	// RateLimit limits the number of entries of each level within every
+	// SampleTick, e.g. "debug=100,info=1000". Sampling and rate limiting
+	// apply to the outputs which enable Sampling, but never to ErrFile, so
+	// that no error is lost there.
+	RateLimit string `env:""`
+
+	// Syslog is the address of syslog, like "udp://host:514", "tcp://host:601"
+	// or "unix:///dev/log", entries are also sent there if it is not empty.
+	Syslog         string `env:""`
+	SyslogFacility string `env:""`
+	// Journald makes entries also sent to JournaldSocket if it is "enable".
+	Journald       string `env:""`
+	JournaldSocket string `env:""`
+	// AppName identifies the entries sent to syslog and journald, it is the
+	// base name of the executable by default.
+	AppName string `env:""`
+
+	writers        []*RotateWriter
+	stop           func()
+	contextHook    *ContextHook
+	closers        []io.Closer
+	main           *switchWriter
+	router         *router
+	asyncs         []*AsyncWriter
+	sampler        *Sampler
+	traceExtractor TraceExtractor
+	levels         *levelRegistry
 }
 
 type Option func(*Logger)
@@ -39,6 +105,12 @@ func WithFormat(format string) Option {
 	}
 }
 
+func WithGCPProject(gcpProject string) Option {
+	return func(logger *Logger) {
+		logger.GCPProject = gcpProject
+	}
+}
+
 func WithLevel(level string) Option {
 	return func(logger *Logger) {
 		logger.Level = level
@@ -51,6 +123,114 @@ func WithReportCaller(reportCaller string) Option {
 	}
 }
 
+func WithOutputs(outputs ...Output) Option {
+	return func(logger *Logger) {
+		logger.Outputs = outputs
+	}
+}
+
+func WithMaxSize(maxSize int64) Option {
+	return func(logger *Logger) {
+		logger.MaxSize = maxSize
+	}
+}
+
+func WithRotateEvery(rotateEvery string) Option {
+	return func(logger *Logger) {
+		logger.RotateEvery = rotateEvery
+	}
+}
+
+func WithMaxBackups(maxBackups int) Option {
+	return func(logger *Logger) {
+		logger.MaxBackups = maxBackups
+	}
+}
+
+func WithMaxAge(maxAge string) Option {
+	return func(logger *Logger) {
+		logger.MaxAge = maxAge
+	}
+}
+
+func WithCompress(compress string) Option {
+	return func(logger *Logger) {
+		logger.Compress = compress
+	}
+}
+
+func WithAsync(async string) Option {
+	return func(logger *Logger) {
+		logger.Async = async
+	}
+}
+
+func WithAsyncBuffer(asyncBuffer int) Option {
+	return func(logger *Logger) {
+		logger.AsyncBuffer = asyncBuffer
+	}
+}
+
+func WithAsyncDropPolicy(asyncDropPolicy string) Option {
+	return func(logger *Logger) {
+		logger.AsyncDropPolicy = asyncDropPolicy
+	}
+}
+
+func WithSampleFirst(sampleFirst int) Option {
+	return func(logger *Logger) {
+		logger.SampleFirst = sampleFirst
+	}
+}
+
+func WithSampleThereafter(sampleThereafter int) Option {
+	return func(logger *Logger) {
+		logger.SampleThereafter = sampleThereafter
+	}
+}
+
+func WithSampleTick(sampleTick string) Option {
+	return func(logger *Logger) {
+		logger.SampleTick = sampleTick
+	}
+}
+
+func WithRateLimit(rateLimit string) Option {
+	return func(logger *Logger) {
+		logger.RateLimit = rateLimit
+	}
+}
+
+func WithSyslog(syslog string) Option {
+	return func(logger *Logger) {
+		logger.Syslog = syslog
+	}
+}
+
+func WithSyslogFacility(syslogFacility string) Option {
+	return func(logger *Logger) {
+		logger.SyslogFacility = syslogFacility
+	}
+}
+
+func WithJournald(journald string) Option {
+	return func(logger *Logger) {
+		logger.Journald = journald
+	}
+}
+
+func WithJournaldSocket(journaldSocket string) Option {
+	return func(logger *Logger) {
+		logger.JournaldSocket = journaldSocket
+	}
+}
+
+func WithAppName(appName string) Option {
+	return func(logger *Logger) {
+		logger.AppName = appName
+	}
+}
+
 func New(opts ...Option) (*Logger, error) {
 	logger := &Logger{}
 
@@ -82,59 +262,291 @@ func (l *Logger) SetDefaults() error {
 		l.ReportCaller = "disable"
 	}
 
+	if len(l.Compress) == 0 {
+		l.Compress = "disable"
+	}
+
+	if len(l.Async) == 0 {
+		l.Async = "disable"
+	}
+
+	if l.AsyncBuffer == 0 {
+		l.AsyncBuffer = 1024
+	}
+
+	if len(l.AsyncDropPolicy) == 0 {
+		l.AsyncDropPolicy = DropPolicyBlock
+	}
+
+	if len(l.SampleTick) == 0 {
+		l.SampleTick = "1s"
+	}
+
+	if len(l.SyslogFacility) == 0 {
+		l.SyslogFacility = "user"
+	}
+
+	if len(l.Journald) == 0 {
+		l.Journald = "disable"
+	}
+
+	if len(l.JournaldSocket) == 0 {
+		l.JournaldSocket = DefaultJournaldSocket
+	}
+
+	if len(l.AppName) == 0 {
+		l.AppName = filepath.Base(os.Args[0])
+	}
+
 	return nil
 }
 
 func (l *Logger) Initialize() error {
+	// The files, connections and the goroutine of a previous Initialize are
+	// released, so that initializing again does not leak them.
+	if err := l.Close(); err != nil {
+		return fmt.Errorf("failed to close: %w", err)
+	}
+
 	l.Logger = logrus.New()
+	l.router = &router{hooks: logrus.LevelHooks{}}
+	l.Logger.AddHook(l.router)
+
+	if l.contextHook == nil {
+		l.contextHook = &ContextHook{}
+	}
+
+	l.AddHook(l.contextHook)
+	l.AddHook(&ErrorHook{})
+
+	if l.ReportCaller == "enable" {
+		l.SetReportCaller(true)
+	}
+
+	l.levels = newLevelRegistry(l.Logger.GetLevel())
+
+	if len(l.Level) != 0 {
+		if err := l.SetLevels(l.Level); err != nil {
+			return fmt.Errorf("failed to set levels: %w", err)
+		}
+	}
 
+	sampler, err := l.newSampler()
+	if err != nil {
+		return fmt.Errorf("failed to new sampler: %w", err)
+	}
+
+	routes, err := l.newRoutes()
+	if err != nil {
+		return fmt.Errorf("failed to new routes: %w", err)
+	}
+
+	// The router formats and writes entries to the outputs by itself.
+	l.router.routes, l.router.sampler = routes, sampler
+	l.Logger.SetOutput(io.Discard)
+	l.SetFormatter(discardFormatter{})
+
+	if len(l.writers) != 0 {
+		l.reopenOnSignal()
+	}
+
+	return nil
+}
+
+// AddHook adds hook, which fires before the entries are written to the
+// outputs, it shadows the method of logrus whose hooks would fire after.
+func (l *Logger) AddHook(hook logrus.Hook) {
+	l.router.addHook(hook)
+}
+
+// newFormatter creates the formatter of format, besides "text" and "json",
+// "otel", "ecs" and "gcp" follow the OpenTelemetry log data model, the
+// Elastic Common Schema and the structured logging of Google Cloud
+// respectively.
+func (l *Logger) newFormatter(format string) logrus.Formatter {
 	callerPrettyfier := func(f *runtime.Frame) (string, string) {
 		return "", fmt.Sprintf("%s:%d", f.Function, f.Line)
 	}
 
-	var formatter logrus.Formatter
-	if strings.ToLower(l.Format) == "json" {
-		formatter = &logrus.JSONFormatter{CallerPrettyfier: callerPrettyfier}
-	} else {
-		formatter = &logrus.TextFormatter{CallerPrettyfier: callerPrettyfier}
+	switch strings.ToLower(format) {
+	case "json":
+		return &logrus.JSONFormatter{CallerPrettyfier: callerPrettyfier}
+	case "otel":
+		return &OTelFormatter{TraceExtractor: l.extractTrace}
+	case "ecs":
+		return &ECSFormatter{TraceExtractor: l.extractTrace}
+	case "gcp":
+		return &GCPFormatter{ProjectID: l.GCPProject, TraceExtractor: l.extractTrace}
 	}
 
-	l.SetFormatter(formatter)
+	return &logrus.TextFormatter{CallerPrettyfier: callerPrettyfier}
+}
 
-	if l.ReportCaller == "enable" {
-		l.SetReportCaller(true)
+// newSampler creates a Sampler according to the sampling fields, nil is
+// returned if sampling is not configured.
+func (l *Logger) newSampler() (*Sampler, error) {
+	l.sampler = nil
+
+	if l.SampleFirst <= 0 && len(l.RateLimit) == 0 {
+		return nil, nil
 	}
 
-	if len(l.Level) != 0 {
-		level, err := logrus.ParseLevel(l.Level)
+	tick, err := time.ParseDuration(l.SampleTick)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse sample tick: %w", err)
+	}
+
+	limits, err := ParseRateLimit(l.RateLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse rate limit: %w", err)
+	}
+
+	l.sampler = &Sampler{Tick: tick, First: l.SampleFirst, Thereafter: l.SampleThereafter, Limits: limits}
+
+	return l.sampler, nil
+}
+
+// Sampled returns the number of entries dropped by sampling and rate limiting.
+func (l *Logger) Sampled() uint64 {
+	if l.sampler == nil {
+		return 0
+	}
+
+	return l.sampler.Dropped()
+}
+
+// openFile opens a RotateWriter for name according to the rotation fields.
+func (l *Logger) openFile(name string) (*RotateWriter, error) {
+	writer := &RotateWriter{
+		Filename:   name,
+		MaxSize:    l.MaxSize * 1024 * 1024,
+		MaxBackups: l.MaxBackups,
+		Compress:   l.Compress == "enable",
+	}
+
+	if len(l.RotateEvery) != 0 {
+		interval, err := time.ParseDuration(l.RotateEvery)
 		if err != nil {
-			return fmt.Errorf("failed to parse level: %w", err)
+			return nil, fmt.Errorf("failed to parse rotate every: %w", err)
 		}
 
-		l.SetLevel(level)
+		writer.Interval = interval
 	}
 
-	if len(l.File) != 0 {
-		writer, err := os.OpenFile(l.File, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o666)
+	if len(l.MaxAge) != 0 {
+		maxAge, err := time.ParseDuration(l.MaxAge)
 		if err != nil {
-			return fmt.Errorf("failed to open file: %w", err)
+			return nil, fmt.Errorf("failed to parse max age: %w", err)
 		}
 
-		l.SetOutput(writer)
+		writer.MaxAge = maxAge
 	}
 
-	if len(l.ErrFile) != 0 {
-		errWriter, err := os.OpenFile(l.ErrFile, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o666)
-		if err != nil {
-			return fmt.Errorf("failed to open err file: %w", err)
+	if err := writer.Reopen(); err != nil {
+		return nil, err
+	}
+
+	l.writers = append(l.writers, writer)
+
+	return writer, nil
+}
+
+// reopenOnSignal reopens all files on SIGHUP until the logger is closed,
+// the goroutine works on a snapshot of the writers, and stop waits for it.
+func (l *Logger) reopenOnSignal() {
+	writers := append([]*RotateWriter(nil), l.writers...)
+	signals := make(chan os.Signal, 1)
+	done, exited := make(chan struct{}), make(chan struct{})
+
+	signal.Notify(signals, syscall.SIGHUP)
+
+	go func() {
+		defer close(exited)
+
+		for {
+			select {
+			case <-signals:
+				for _, writer := range writers {
+					if err := writer.Reopen(); err != nil {
+						_, _ = fmt.Fprintf(os.Stderr, "conflog: failed to reopen: %v\n", err)
+					}
+				}
+			case <-done:
+				return
+			}
 		}
+	}()
 
-		l.AddHook(lfshook.NewHook(lfshook.WriterMap{
-			logrus.ErrorLevel: errWriter,
-			logrus.FatalLevel: errWriter,
-			logrus.PanicLevel: errWriter,
-		}, formatter))
+	l.stop = func() {
+		signal.Stop(signals)
+		close(done)
+		<-exited
+	}
+}
+
+// Flush waits until all entries buffered in async mode have been written.
+func (l *Logger) Flush() error {
+	for _, async := range l.asyncs {
+		if err := async.Flush(); err != nil {
+			return fmt.Errorf("failed to flush async writer: %w", err)
+		}
 	}
 
 	return nil
 }
+
+// Dropped returns the number of entries dropped in async mode, summed over
+// all the outputs.
+func (l *Logger) Dropped() uint64 {
+	var dropped uint64
+
+	for _, async := range l.asyncs {
+		dropped += async.Dropped()
+	}
+
+	return dropped
+}
+
+// Close drains the entries buffered in async mode, stops reopening on
+// SIGHUP, and closes all files and connections. All of them are closed
+// even if some fail, and the first error is returned.
+func (l *Logger) Close() error {
+	var firstErr error
+
+	keep := func(err error) {
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	for _, async := range l.asyncs {
+		if err := async.Close(); err != nil {
+			keep(fmt.Errorf("failed to close async writer: %w", err))
+		}
+	}
+
+	l.asyncs = nil
+
+	if l.stop != nil {
+		l.stop()
+		l.stop = nil
+	}
+
+	for _, writer := range l.writers {
+		if err := writer.Close(); err != nil {
+			keep(fmt.Errorf("failed to close writer: %w", err))
+		}
+	}
+
+	l.writers = nil
+
+	for _, closer := range l.closers {
+		if err := closer.Close(); err != nil {
+			keep(fmt.Errorf("failed to close closer: %w", err))
+		}
+	}
+
+	l.closers = nil
+
+	return firstErr
+}
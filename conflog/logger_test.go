@@ -4,9 +4,17 @@ import (
 	"testing"
 
 	"github.com/rbee3u/gohelp/conflog"
+	"github.com/rbee3u/gohelp/conflog/conflogtest"
+	"github.com/sirupsen/logrus"
 )
 
 func TestLogger(t *testing.T) {
-	l, _ := conflog.New(conflog.WithReportCaller("enable"))
+	l, r := conflogtest.New(t, conflog.WithReportCaller("enable"), conflog.WithLevel("info"))
+
+	l.Debug("Hidden")
 	l.Info("Hello")
+
+	r.AssertContains(t, "Hello")
+	r.AssertNotContains(t, "Hidden")
+	r.AssertCount(t, logrus.InfoLevel, 1)
 }
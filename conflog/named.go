@@ -0,0 +1,233 @@
+package conflog
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// NameField is the field which carries the name of named loggers.
+const NameField = "logger"
+
+// ParseLevels parses text like "info,db=debug,http=warn" into the global
+// level and the levels overridden by name, the global level is info if it
+// is omitted.
+func ParseLevels(text string) (logrus.Level, map[string]logrus.Level, error) {
+	global, overrides := logrus.InfoLevel, map[string]logrus.Level{}
+
+	for _, item := range strings.Split(text, ",") {
+		if item = strings.TrimSpace(item); len(item) == 0 {
+			continue
+		}
+
+		kv := strings.SplitN(item, "=", 2)
+
+		level, err := logrus.ParseLevel(strings.TrimSpace(kv[len(kv)-1]))
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed to parse level: %w", err)
+		}
+
+		if len(kv) == 1 {
+			global = level
+		} else {
+			overrides[strings.TrimSpace(kv[0])] = level
+		}
+	}
+
+	return global, overrides, nil
+}
+
+// FormatLevels is the inverse of ParseLevels, the overrides are sorted by
+// name.
+func FormatLevels(global logrus.Level, overrides map[string]logrus.Level) string {
+	items := make([]string, 0, len(overrides))
+	for name, level := range overrides {
+		items = append(items, name+"="+level.String())
+	}
+
+	sort.Strings(items)
+
+	return strings.Join(append([]string{global.String()}, items...), ",")
+}
+
+// levelRegistry keeps the levels and the loggers of names, a name without
+// overridden level inherits the level of its nearest dotted parent, e.g.
+// "db.pool" inherits "db", and finally the global level.
+type levelRegistry struct {
+	mu        sync.Mutex
+	global    logrus.Level
+	overrides map[string]logrus.Level
+	loggers   map[string]*logrus.Logger
+}
+
+func (r *levelRegistry) effective(name string) logrus.Level {
+	for n := name; len(n) != 0; {
+		if level, ok := r.overrides[n]; ok {
+			return level
+		}
+
+		i := strings.LastIndexByte(n, '.')
+		if i < 0 {
+			break
+		}
+
+		n = n[:i]
+	}
+
+	return r.global
+}
+
+func (r *levelRegistry) apply(root *logrus.Logger) {
+	root.SetLevel(r.global)
+
+	for name, logger := range r.loggers {
+		logger.SetLevel(r.effective(name))
+	}
+}
+
+// Named returns an entry of the logger named name, whose level can be
+// overridden separately, e.g. by the Level "info,db=debug". The entry shares
+// the output, formatter and hooks with l, and carries the name as field.
+func (l *Logger) Named(name string) *logrus.Entry {
+	r := l.levels
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	logger, ok := r.loggers[name]
+	if !ok {
+		logger = &logrus.Logger{
+			Out:          parentWriter{l},
+			Hooks:        l.Hooks,
+			Formatter:    parentFormatter{l},
+			ReportCaller: l.Logger.ReportCaller,
+			Level:        r.effective(name),
+			ExitFunc:     l.ExitFunc,
+		}
+		r.loggers[name] = logger
+	}
+
+	return logger.WithField(NameField, name)
+}
+
+// SetLevel sets the global level, it shadows the method of logrus so that
+// the named loggers follow.
+func (l *Logger) SetLevel(level logrus.Level) {
+	r := l.levels
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.global = level
+	r.apply(l.Logger)
+}
+
+// SetLevels replaces both the global level and all the overrides by text,
+// which is in the form accepted by ParseLevels.
+func (l *Logger) SetLevels(text string) error {
+	global, overrides, err := ParseLevels(text)
+	if err != nil {
+		return err
+	}
+
+	r := l.levels
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.global, r.overrides = global, overrides
+	r.apply(l.Logger)
+
+	return nil
+}
+
+// Levels returns the current levels in the form accepted by SetLevels.
+func (l *Logger) Levels() string {
+	r := l.levels
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return FormatLevels(r.global, r.overrides)
+}
+
+// SetNamedLevel overrides the level of name, an empty name means the
+// global level.
+func (l *Logger) SetNamedLevel(name string, level logrus.Level) {
+	if len(name) == 0 {
+		l.SetLevel(level)
+
+		return
+	}
+
+	r := l.levels
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.overrides[name] = level
+	r.apply(l.Logger)
+}
+
+// UnsetNamedLevel removes the overridden level of name, so that it inherits
+// the level of its parent again.
+func (l *Logger) UnsetNamedLevel(name string) {
+	r := l.levels
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.overrides, name)
+	r.apply(l.Logger)
+}
+
+// NamedLevel returns the effective level of name, an empty name means the
+// global level.
+func (l *Logger) NamedLevel(name string) logrus.Level {
+	r := l.levels
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.effective(name)
+}
+
+func newLevelRegistry(global logrus.Level) *levelRegistry {
+	return &levelRegistry{
+		global:    global,
+		overrides: map[string]logrus.Level{},
+		loggers:   map[string]*logrus.Logger{},
+	}
+}
+
+// parentWriter writes to the current output of the parent logger, so that
+// changes of the parent output are followed by the named loggers.
+type parentWriter struct {
+	l *Logger
+}
+
+func (w parentWriter) Write(p []byte) (int, error) {
+	n, err := w.l.Out.Write(p)
+	if err != nil {
+		return n, fmt.Errorf("failed to write parent: %w", err)
+	}
+
+	return n, nil
+}
+
+// parentFormatter formats by the current formatter of the parent logger.
+type parentFormatter struct {
+	l *Logger
+}
+
+func (f parentFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	data, err := f.l.Formatter.Format(entry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to format parent: %w", err)
+	}
+
+	return data, nil
+}
@@ -0,0 +1,76 @@
+package conflog_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/rbee3u/gohelp/conflog"
+	"github.com/sirupsen/logrus"
+)
+
+func TestNamed(t *testing.T) {
+	l, err := conflog.New(conflog.WithLevel("info,db=debug,http=warn"))
+	if err != nil {
+		t.Fatalf("failed to new: %v", err)
+	}
+
+	buf := new(bytes.Buffer)
+	l.SetOutput(buf)
+
+	l.Debug("root-debug")
+	l.Named("db").Debug("db-debug")
+	l.Named("db.pool").Debug("pool-debug")
+	l.Named("http").Info("http-info")
+	l.Named("cache").Info("cache-info")
+
+	got := buf.String()
+	for _, want := range []string{"db-debug", "pool-debug", "cache-info", "logger=db.pool"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expect %q to contain %q", got, want)
+		}
+	}
+
+	for _, unwanted := range []string{"root-debug", "http-info"} {
+		if strings.Contains(got, unwanted) {
+			t.Errorf("expect %q not to contain %q", got, unwanted)
+		}
+	}
+
+	if err := l.SetLevels("warn,http=info"); err != nil {
+		t.Fatalf("failed to set levels: %v", err)
+	}
+
+	if got, want := l.Levels(), "warning,http=info"; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+
+	if got, want := l.NamedLevel("db.pool"), logrus.WarnLevel; got != want {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+
+	l.SetNamedLevel("db", logrus.TraceLevel)
+	l.SetLevel(logrus.ErrorLevel)
+
+	if got, want := l.Levels(), "error,db=trace,http=info"; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+
+	buf.Reset()
+	l.Named("db.pool").Trace("pool-trace")
+	l.Named("cache").Warn("cache-warn")
+
+	if got := buf.String(); !strings.Contains(got, "pool-trace") || strings.Contains(got, "cache-warn") {
+		t.Errorf("unexpected output: %q", got)
+	}
+
+	l.UnsetNamedLevel("db")
+
+	if got, want := l.NamedLevel("db.pool"), logrus.ErrorLevel; got != want {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+
+	if _, err := conflog.New(conflog.WithLevel("info,db=loud")); err == nil {
+		t.Errorf("expect err not to be nil")
+	}
+}
@@ -0,0 +1,327 @@
+package conflog
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// RotateWriter is an io.WriteCloser which writes to Filename, and rotates it
+// when its size exceeds MaxSize or every Interval. The rotated backups are
+// named after the rotation time, e.g. "app-2006-01-02T15-04-05.000.log", a
+// counter is appended to the time if the name is taken, e.g.
+// "app-2006-01-02T15-04-05.000.1.log". At most MaxBackups of them are kept
+// for no longer than MaxAge, and they are compressed with gzip if Compress
+// is true. Zero values disable the limits.
+type RotateWriter struct {
+	Filename   string
+	MaxSize    int64
+	Interval   time.Duration
+	MaxBackups int
+	MaxAge     time.Duration
+	Compress   bool
+
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	deadline time.Time
+	millMu   sync.Mutex
+}
+
+func (w *RotateWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		if err := w.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	if w.shouldRotate(int64(len(p))) {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+
+	if err != nil {
+		return n, fmt.Errorf("failed to write: %w", err)
+	}
+
+	return n, nil
+}
+
+// Rotate closes the current file, moves it aside as a backup, and opens a
+// new one.
+func (w *RotateWriter) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.rotate()
+}
+
+// Reopen closes and reopens the current file without renaming it, which is
+// needed after the file has been moved by an external tool like logrotate.
+func (w *RotateWriter) Reopen() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.close(); err != nil {
+		return err
+	}
+
+	return w.open()
+}
+
+func (w *RotateWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.close()
+}
+
+func (w *RotateWriter) shouldRotate(n int64) bool {
+	if w.MaxSize > 0 && w.size > 0 && w.size+n > w.MaxSize {
+		return true
+	}
+
+	return w.Interval > 0 && !time.Now().Before(w.deadline)
+}
+
+func (w *RotateWriter) open() error {
+	if err := os.MkdirAll(filepath.Dir(w.Filename), 0o755); err != nil {
+		return fmt.Errorf("failed to make dir: %w", err)
+	}
+
+	file, err := os.OpenFile(w.Filename, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o666)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+
+		return fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	w.file, w.size = file, info.Size()
+
+	if w.Interval > 0 {
+		w.deadline = time.Now().Truncate(w.Interval).Add(w.Interval)
+	}
+
+	return nil
+}
+
+func (w *RotateWriter) close() error {
+	if w.file == nil {
+		return nil
+	}
+
+	err := w.file.Close()
+	w.file = nil
+
+	if err != nil {
+		return fmt.Errorf("failed to close file: %w", err)
+	}
+
+	return nil
+}
+
+func (w *RotateWriter) rotate() error {
+	if err := w.close(); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(w.Filename); err == nil {
+		if err := os.Rename(w.Filename, w.backupName(time.Now())); err != nil {
+			return fmt.Errorf("failed to rename file: %w", err)
+		}
+	}
+
+	if err := w.open(); err != nil {
+		return err
+	}
+
+	go w.mill()
+
+	return nil
+}
+
+// backupName returns the first name of the backup at t which is not taken
+// by another backup, compressed or not.
+func (w *RotateWriter) backupName(t time.Time) string {
+	dir, base := filepath.Split(w.Filename)
+	ext := filepath.Ext(base)
+	stamp := strings.TrimSuffix(base, ext) + "-" + t.Format(backupTimeFormat)
+
+	for seq := 0; ; seq++ {
+		name := stamp
+		if seq > 0 {
+			name += "." + strconv.Itoa(seq)
+		}
+
+		name = filepath.Join(dir, name+ext)
+
+		if !exists(name) && !exists(name+".gz") {
+			return name
+		}
+	}
+}
+
+func exists(name string) bool {
+	_, err := os.Lstat(name)
+
+	return err == nil
+}
+
+type backup struct {
+	name string
+	time time.Time
+	seq  int
+}
+
+// parseStamp parses the time and the optional counter of a backup.
+func parseStamp(stamp string) (time.Time, int, error) {
+	seq := 0
+
+	if len(stamp) > len(backupTimeFormat) {
+		n, err := strconv.Atoi(strings.TrimPrefix(stamp[len(backupTimeFormat):], "."))
+		if err != nil || n <= 0 || stamp[len(backupTimeFormat)] != '.' {
+			return time.Time{}, 0, fmt.Errorf("invalid counter: %q", stamp)
+		}
+
+		stamp, seq = stamp[:len(backupTimeFormat)], n
+	}
+
+	t, err := time.ParseInLocation(backupTimeFormat, stamp, time.Local)
+	if err != nil {
+		return time.Time{}, 0, fmt.Errorf("failed to parse time: %w", err)
+	}
+
+	return t, seq, nil
+}
+
+// backups returns the backups of Filename, the newest first.
+func (w *RotateWriter) backups() ([]backup, error) {
+	dir, base := filepath.Split(w.Filename)
+	ext := filepath.Ext(base)
+	prefix := strings.TrimSuffix(base, ext) + "-"
+
+	if len(dir) == 0 {
+		dir = "."
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dir: %w", err)
+	}
+
+	var backups []backup
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+
+		stamp := strings.TrimPrefix(strings.TrimSuffix(name, ".gz"), prefix)
+		if !strings.HasSuffix(stamp, ext) {
+			continue
+		}
+
+		t, seq, err := parseStamp(strings.TrimSuffix(stamp, ext))
+		if err != nil {
+			continue
+		}
+
+		backups = append(backups, backup{name: filepath.Join(dir, name), time: t, seq: seq})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		if !backups[i].time.Equal(backups[j].time) {
+			return backups[i].time.After(backups[j].time)
+		}
+
+		return backups[i].seq > backups[j].seq
+	})
+
+	return backups, nil
+}
+
+// mill removes the stale backups and compresses the remaining ones, errors
+// are ignored since there is nowhere to report them.
+func (w *RotateWriter) mill() {
+	w.millMu.Lock()
+	defer w.millMu.Unlock()
+
+	backups, err := w.backups()
+	if err != nil {
+		return
+	}
+
+	for i, b := range backups {
+		if (w.MaxBackups > 0 && i >= w.MaxBackups) ||
+			(w.MaxAge > 0 && time.Since(b.time) > w.MaxAge) {
+			_ = os.Remove(b.name)
+
+			continue
+		}
+
+		if w.Compress && !strings.HasSuffix(b.name, ".gz") {
+			_ = compressFile(b.name)
+		}
+	}
+}
+
+func compressFile(name string) error {
+	src, err := os.Open(name)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(name+".gz", os.O_WRONLY|os.O_TRUNC|os.O_CREATE, 0o666)
+	if err != nil {
+		return fmt.Errorf("failed to open gz file: %w", err)
+	}
+
+	gz := gzip.NewWriter(dst)
+
+	if _, err := io.Copy(gz, src); err != nil {
+		_ = dst.Close()
+		_ = os.Remove(name + ".gz")
+
+		return fmt.Errorf("failed to copy: %w", err)
+	}
+
+	if err := gz.Close(); err != nil {
+		_ = dst.Close()
+		_ = os.Remove(name + ".gz")
+
+		return fmt.Errorf("failed to close gz writer: %w", err)
+	}
+
+	if err := dst.Close(); err != nil {
+		return fmt.Errorf("failed to close gz file: %w", err)
+	}
+
+	if err := os.Remove(name); err != nil {
+		return fmt.Errorf("failed to remove file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,157 @@
+package conflog_test
+
+import (
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/rbee3u/gohelp/conflog"
+)
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); {
+		if cond() {
+			return
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatalf("timeout waiting for condition")
+}
+
+func TestRotateWriter(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "app.log")
+
+	w := &conflog.RotateWriter{Filename: name, MaxSize: 10, MaxBackups: 1, Compress: true}
+	defer w.Close()
+
+	for _, line := range []string{"first\n", "second\n", "third\n"} {
+		if _, err := w.Write([]byte(line)); err != nil {
+			t.Fatalf("failed to write: %v", err)
+		}
+	}
+
+	content, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatalf("failed to read file: %v", err)
+	}
+
+	if got, want := string(content), "third\n"; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+
+	waitFor(t, func() bool {
+		matches, _ := filepath.Glob(filepath.Join(dir, "app-*"))
+
+		return len(matches) == 1 && strings.HasSuffix(matches[0], ".log.gz")
+	})
+}
+
+func TestRotateWriterCollision(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "app.log")
+
+	w := &conflog.RotateWriter{Filename: name}
+	defer w.Close()
+
+	for _, line := range []string{"first\n", "second\n", "third\n"} {
+		if _, err := w.Write([]byte(line)); err != nil {
+			t.Fatalf("failed to write: %v", err)
+		}
+
+		if err := w.Rotate(); err != nil {
+			t.Fatalf("failed to rotate: %v", err)
+		}
+	}
+
+	matches, _ := filepath.Glob(filepath.Join(dir, "app-*"))
+	if got, want := len(matches), 3; got != want {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+}
+
+func TestLoggerReopenOnSignal(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "app.log")
+
+	l, err := conflog.New(conflog.WithFile(name))
+	if err != nil {
+		t.Fatalf("failed to new: %v", err)
+	}
+	defer l.Close()
+
+	l.Info("before")
+
+	if err := os.Rename(name, name+".1"); err != nil {
+		t.Fatalf("failed to rename: %v", err)
+	}
+
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatalf("failed to kill: %v", err)
+	}
+
+	waitFor(t, func() bool {
+		_, err := os.Stat(name)
+
+		return err == nil
+	})
+
+	l.Info("after")
+
+	content, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatalf("failed to read file: %v", err)
+	}
+
+	if !strings.Contains(string(content), "after") || strings.Contains(string(content), "before") {
+		t.Errorf("unexpected content: %q", content)
+	}
+}
+
+func TestLoggerInitializeTwice(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "app.log")
+
+	l, err := conflog.New(conflog.WithFile(name))
+	if err != nil {
+		t.Fatalf("failed to new: %v", err)
+	}
+	defer l.Close()
+
+	// SIGHUP must not kill the test between two calls of Initialize.
+	hup := make(chan os.Signal, 3)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	for i := 0; i < 3; i++ {
+		// The goroutine reopening on SIGHUP must not race with Initialize.
+		_ = syscall.Kill(syscall.Getpid(), syscall.SIGHUP)
+
+		if err := l.Initialize(); err != nil {
+			t.Fatalf("failed to initialize: %v", err)
+		}
+	}
+
+	l.Info("hello")
+
+	if err := l.Close(); err != nil {
+		t.Fatalf("failed to close: %v", err)
+	}
+
+	content, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatalf("failed to read file: %v", err)
+	}
+
+	if got, want := strings.Count(string(content), "hello"), 1; got != want {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+}
@@ -0,0 +1,306 @@
+package conflog
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Output is a destination of entries, an entry is written to every output
+// whose level range covers the level of the entry.
+type Output struct {
+	// Path is one of "stdout", "stderr", "journald", a syslog address like
+	// "udp://host:514", "tcp://host:601" or "unix:///dev/log", or a file.
+	Path string `env:""`
+	// Format is the format of the output, it is Logger.Format by default.
+	Format string `env:""`
+	// MinLevel and MaxLevel bound the severity of entries, e.g. MinLevel
+	// "error" with MaxLevel "panic" routes only errors and above.
+	MinLevel string `env:""`
+	MaxLevel string `env:""`
+	// Sampling makes the output subject to sampling and rate limiting if it
+	// is "enable".
+	Sampling string `env:""`
+}
+
+func (o *Output) SetDefaults() error {
+	if len(o.MinLevel) == 0 {
+		o.MinLevel = logrus.TraceLevel.String()
+	}
+
+	if len(o.MaxLevel) == 0 {
+		o.MaxLevel = logrus.PanicLevel.String()
+	}
+
+	if len(o.Sampling) == 0 {
+		o.Sampling = "enable"
+	}
+
+	return nil
+}
+
+type route struct {
+	min       logrus.Level
+	max       logrus.Level
+	sampled   bool
+	formatter logrus.Formatter
+	writer    io.Writer
+}
+
+// router is the hook which takes over the output of the logger, it fires
+// the hooks added by Logger.AddHook, decides sampling once for each entry,
+// and writes the entry to the routes covering its level. Being a hook, the
+// writes are done outside the lock of the logger, and the formatter of the
+// logger is left with nothing to do.
+type router struct {
+	routes  []*route
+	sampler *Sampler
+
+	mu    sync.Mutex
+	hooks logrus.LevelHooks
+}
+
+func (r *router) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (r *router) addHook(hook logrus.Hook) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, level := range hook.Levels() {
+		r.hooks[level] = append(r.hooks[level], hook)
+	}
+}
+
+func (r *router) Fire(entry *logrus.Entry) error {
+	if frame, ok := callerFromContext(entry.Context); ok && entry.HasCaller() {
+		entry.Caller = frame
+	}
+
+	r.mu.Lock()
+	hooks := append([]logrus.Hook(nil), r.hooks[entry.Level]...)
+	r.mu.Unlock()
+
+	for _, hook := range hooks {
+		if err := hook.Fire(entry); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Failed to fire hook: %v\n", err)
+		}
+	}
+
+	allowed := r.sampler == nil || r.sampler.Allow(entry)
+
+	var firstErr error
+
+	for _, rt := range r.routes {
+		if entry.Level > rt.min || entry.Level < rt.max || (rt.sampled && !allowed) {
+			continue
+		}
+
+		if err := rt.write(entry); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+// discardFormatter formats nothing, since the router has written the entry.
+type discardFormatter struct{}
+
+func (discardFormatter) Format(*logrus.Entry) ([]byte, error) {
+	return nil, nil
+}
+
+func (rt *route) write(entry *logrus.Entry) error {
+	// The buffer of entry is shared, every route formats into its own one.
+	e := *entry
+	e.Buffer = nil
+
+	data, err := rt.formatter.Format(&e)
+	if err != nil {
+		return fmt.Errorf("failed to format: %w", err)
+	}
+
+	if lw, ok := rt.writer.(LevelWriter); ok {
+		_, err = lw.WriteLevel(entry.Level, data)
+	} else {
+		_, err = rt.writer.Write(data)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to write: %w", err)
+	}
+
+	return nil
+}
+
+// switchWriter is the writer of the main output, which can be switched by
+// SetOutput, and serializes writes to the underlying writer.
+type switchWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (sw *switchWriter) Write(p []byte) (int, error) {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+
+	n, err := sw.w.Write(p)
+	if err != nil {
+		return n, fmt.Errorf("failed to write: %w", err)
+	}
+
+	return n, nil
+}
+
+func (sw *switchWriter) set(w io.Writer) {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+
+	sw.w = w
+}
+
+// SetOutput replaces the writer of the main output, i.e. File, or stderr if
+// neither File nor Outputs is configured, or else the first of Outputs.
+func (l *Logger) SetOutput(output io.Writer) {
+	if l.main != nil {
+		l.main.set(output)
+	}
+}
+
+// outputs resolves the outputs to be routed to, the first of them is the
+// main output. The ErrFile, Syslog and Journald are shorthands of outputs.
+func (l *Logger) outputs() []Output {
+	var outputs []Output
+
+	switch {
+	case len(l.File) != 0:
+		outputs = append(outputs, Output{Path: l.File})
+	case len(l.Outputs) == 0:
+		outputs = append(outputs, Output{Path: "stderr"})
+	}
+
+	outputs = append(outputs, l.Outputs...)
+
+	if len(l.ErrFile) != 0 {
+		outputs = append(outputs, Output{Path: l.ErrFile, MinLevel: logrus.ErrorLevel.String(), Sampling: "disable"})
+	}
+
+	if len(l.Syslog) != 0 {
+		outputs = append(outputs, Output{Path: l.Syslog})
+	}
+
+	if l.Journald == "enable" {
+		outputs = append(outputs, Output{Path: "journald"})
+	}
+
+	for i := range outputs {
+		_ = outputs[i].SetDefaults()
+	}
+
+	return outputs
+}
+
+// newRoutes opens the outputs, the writers are wrapped by AsyncWriter in
+// async mode. If any output fails, the ones already opened are closed.
+func (l *Logger) newRoutes() ([]*route, error) {
+	outputs := l.outputs()
+	routes := make([]*route, 0, len(outputs))
+
+	l.main, l.asyncs = nil, nil
+
+	for i, output := range outputs {
+		rt, err := l.newRoute(output)
+		if err != nil {
+			_ = l.Close()
+
+			return nil, fmt.Errorf("failed to new route of %q: %w", output.Path, err)
+		}
+
+		if i == 0 {
+			l.main = &switchWriter{w: rt.writer}
+			rt.writer = l.main
+		}
+
+		if l.Async == "enable" {
+			async, err := NewAsyncWriter(rt.writer, l.AsyncBuffer, l.AsyncDropPolicy)
+			if err != nil {
+				_ = l.Close()
+
+				return nil, fmt.Errorf("failed to new async writer: %w", err)
+			}
+
+			l.asyncs = append(l.asyncs, async)
+			rt.writer = async
+		}
+
+		routes = append(routes, rt)
+	}
+
+	return routes, nil
+}
+
+func (l *Logger) newRoute(output Output) (*route, error) {
+	min, err := logrus.ParseLevel(output.MinLevel)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse min level: %w", err)
+	}
+
+	max, err := logrus.ParseLevel(output.MaxLevel)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse max level: %w", err)
+	}
+
+	format := output.Format
+	if len(format) == 0 {
+		format = l.Format
+	}
+
+	writer, err := l.openOutput(output.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &route{
+		min:       min,
+		max:       max,
+		sampled:   output.Sampling == "enable",
+		formatter: l.newFormatter(format),
+		writer:    writer,
+	}, nil
+}
+
+func (l *Logger) openOutput(path string) (io.Writer, error) {
+	switch {
+	case path == "stdout":
+		return os.Stdout, nil
+	case path == "stderr":
+		return os.Stderr, nil
+	case path == "journald":
+		writer := NewJournaldWriter(l.JournaldSocket, l.AppName)
+		l.closers = append(l.closers, writer)
+
+		return writer, nil
+	case strings.HasPrefix(path, "udp://"), strings.HasPrefix(path, "tcp://"), strings.HasPrefix(path, "unix://"):
+		writer, err := NewSyslogWriter(path, l.SyslogFacility, l.AppName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to new syslog writer: %w", err)
+		}
+
+		l.closers = append(l.closers, writer)
+
+		return writer, nil
+	}
+
+	writer, err := l.openFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+
+	return writer, nil
+}
@@ -0,0 +1,174 @@
+package conflog_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rbee3u/gohelp/conflog"
+	"github.com/sirupsen/logrus"
+)
+
+func TestLoggerOutputs(t *testing.T) {
+	dir := t.TempDir()
+	allFile, errFile := filepath.Join(dir, "all.log"), filepath.Join(dir, "err.log")
+
+	l, err := conflog.New(conflog.WithOutputs(
+		conflog.Output{Path: allFile, Format: "json", MinLevel: "debug"},
+		conflog.Output{Path: errFile, MinLevel: "error"},
+	))
+	if err != nil {
+		t.Fatalf("failed to new: %v", err)
+	}
+	defer l.Close()
+
+	l.SetLevel(logrus.TraceLevel)
+	l.Trace("trace")
+	l.Info("info")
+	l.Error("error")
+
+	all, err := os.ReadFile(allFile)
+	if err != nil {
+		t.Fatalf("failed to read file: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(all)), "\n")
+	if got, want := len(lines), 2; got != want {
+		t.Fatalf("got: %v, want: %v", got, want)
+	}
+
+	var record map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &record); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+
+	if got, want := record["msg"], "info"; got != want {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+
+	errs, err := os.ReadFile(errFile)
+	if err != nil {
+		t.Fatalf("failed to read file: %v", err)
+	}
+
+	if got, want := string(errs), "msg=error"; !strings.Contains(got, want) || strings.Contains(got, "msg=info") {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+}
+
+func TestLoggerOutputsMaxLevel(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "app.log")
+
+	l, err := conflog.New(conflog.WithOutputs(conflog.Output{Path: file, MaxLevel: "warn"}))
+	if err != nil {
+		t.Fatalf("failed to new: %v", err)
+	}
+	defer l.Close()
+
+	l.Info("info")
+	l.Error("error")
+
+	content, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("failed to read file: %v", err)
+	}
+
+	if got, want := bytes.Count(content, []byte("\n")), 1; got != want {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+}
+
+func TestLoggerOutputsSampling(t *testing.T) {
+	dir := t.TempDir()
+	sampled, unsampled := filepath.Join(dir, "sampled.log"), filepath.Join(dir, "unsampled.log")
+
+	l, err := conflog.New(
+		conflog.WithRateLimit("info=2"),
+		conflog.WithOutputs(
+			conflog.Output{Path: sampled},
+			conflog.Output{Path: unsampled, Sampling: "disable"},
+		),
+	)
+	if err != nil {
+		t.Fatalf("failed to new: %v", err)
+	}
+	defer l.Close()
+
+	for i := 0; i < 5; i++ {
+		l.WithField("i", i).Info("hello")
+	}
+
+	for name, want := range map[string]int{sampled: 2, unsampled: 5} {
+		content, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatalf("failed to read file: %v", err)
+		}
+
+		if got := bytes.Count(content, []byte("\n")); got != want {
+			t.Errorf("got: %v, want: %v", got, want)
+		}
+	}
+}
+
+func TestLoggerOutputsInvalidLevel(t *testing.T) {
+	_, err := conflog.New(conflog.WithOutputs(conflog.Output{Path: "stderr", MinLevel: "verbose"}))
+	if err == nil {
+		t.Errorf("got: %v, want: error", err)
+	}
+}
+
+func TestLoggerOutputsSetOutput(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "app.log")
+
+	l, err := conflog.New(conflog.WithOutputs(conflog.Output{Path: file}))
+	if err != nil {
+		t.Fatalf("failed to new: %v", err)
+	}
+	defer l.Close()
+
+	var buf bytes.Buffer
+
+	l.SetOutput(&buf)
+	l.Info("hello")
+
+	if !strings.Contains(buf.String(), "hello") {
+		t.Errorf("unexpected output: %q", buf.String())
+	}
+}
+
+// fieldHook adds the field key with value to every entry.
+type fieldHook struct {
+	key   string
+	value string
+}
+
+func (h fieldHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h fieldHook) Fire(entry *logrus.Entry) error {
+	entry.Data[h.key] = h.value
+
+	return nil
+}
+
+func TestLoggerAddHook(t *testing.T) {
+	l, err := conflog.New()
+	if err != nil {
+		t.Fatalf("failed to new: %v", err)
+	}
+	defer l.Close()
+
+	var buf bytes.Buffer
+
+	l.SetOutput(&buf)
+	l.AddHook(fieldHook{key: "hooked", value: "yes"})
+	l.Info("hello")
+
+	if !strings.Contains(buf.String(), "hooked=yes") {
+		t.Errorf("unexpected output: %q", buf.String())
+	}
+}
@@ -0,0 +1,117 @@
+package conflog
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Sampler decides whether an entry should be logged. Within every Tick, the
+// first First entries with the same level and message are allowed, and then
+// every Thereafter-th of them is allowed. Besides, at most Limits[level]
+// entries of each level are allowed within every Tick. Tick defaults to one
+// second, and zero values of the others disable the corresponding rules.
+type Sampler struct {
+	Tick       time.Duration
+	First      int
+	Thereafter int
+	Limits     map[logrus.Level]int
+
+	dropped uint64
+
+	mu       sync.Mutex
+	start    time.Time
+	messages map[sampleKey]int
+	levels   map[logrus.Level]int
+}
+
+type sampleKey struct {
+	level   logrus.Level
+	message string
+}
+
+// Allow reports whether entry should be logged, and counts the entry.
+func (s *Sampler) Allow(entry *logrus.Entry) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := entry.Time
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	tick := s.Tick
+	if tick <= 0 {
+		tick = time.Second
+	}
+
+	if s.messages == nil || now.Sub(s.start) >= tick || now.Before(s.start) {
+		s.start = now
+		s.messages = map[sampleKey]int{}
+		s.levels = map[logrus.Level]int{}
+	}
+
+	if s.First > 0 {
+		key := sampleKey{level: entry.Level, message: entry.Message}
+		s.messages[key]++
+
+		if n := s.messages[key]; n > s.First && (s.Thereafter <= 0 || (n-s.First)%s.Thereafter != 0) {
+			atomic.AddUint64(&s.dropped, 1)
+
+			return false
+		}
+	}
+
+	if limit, ok := s.Limits[entry.Level]; ok {
+		if s.levels[entry.Level] >= limit {
+			atomic.AddUint64(&s.dropped, 1)
+
+			return false
+		}
+
+		s.levels[entry.Level]++
+	}
+
+	return true
+}
+
+// Dropped returns the number of entries which are not allowed so far.
+func (s *Sampler) Dropped() uint64 {
+	return atomic.LoadUint64(&s.dropped)
+}
+
+// ParseRateLimit parses limits like "debug=100,info=1000" into a map from
+// level to the number of entries allowed within a tick.
+func ParseRateLimit(text string) (map[logrus.Level]int, error) {
+	limits := map[logrus.Level]int{}
+
+	for _, item := range strings.Split(text, ",") {
+		if item = strings.TrimSpace(item); len(item) == 0 {
+			continue
+		}
+
+		kv := strings.SplitN(item, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid rate limit: %q", item)
+		}
+
+		level, err := logrus.ParseLevel(strings.TrimSpace(kv[0]))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse level: %w", err)
+		}
+
+		limit, err := strconv.Atoi(strings.TrimSpace(kv[1]))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse limit: %w", err)
+		}
+
+		limits[level] = limit
+	}
+
+	return limits, nil
+}
@@ -0,0 +1,92 @@
+package conflog_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rbee3u/gohelp/conflog"
+	"github.com/sirupsen/logrus"
+)
+
+func TestSampler(t *testing.T) {
+	s := &conflog.Sampler{Tick: time.Second, First: 2, Thereafter: 3}
+	start := time.Now()
+
+	allowed := 0
+
+	for i := 0; i < 10; i++ {
+		entry := &logrus.Entry{Time: start, Level: logrus.InfoLevel, Message: "hot"}
+		if s.Allow(entry) {
+			allowed++
+		}
+	}
+
+	// The 1st, 2nd, 5th and 8th entries are allowed.
+	if got, want := allowed, 4; got != want {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+
+	entry := &logrus.Entry{Time: start.Add(time.Second), Level: logrus.InfoLevel, Message: "hot"}
+	if !s.Allow(entry) {
+		t.Errorf("expect entry of next tick to be allowed")
+	}
+
+	if got, want := s.Dropped(), uint64(6); got != want {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+}
+
+func TestParseRateLimit(t *testing.T) {
+	limits, err := conflog.ParseRateLimit("debug=1, info=2,")
+	if err != nil {
+		t.Fatalf("failed to parse rate limit: %v", err)
+	}
+
+	if len(limits) != 2 || limits[logrus.DebugLevel] != 1 || limits[logrus.InfoLevel] != 2 {
+		t.Errorf("unexpected limits: %v", limits)
+	}
+
+	for _, text := range []string{"debug", "x=1", "info=x"} {
+		if _, err := conflog.ParseRateLimit(text); err == nil {
+			t.Errorf("expect err of %q not to be nil", text)
+		}
+	}
+}
+
+func TestLoggerRateLimit(t *testing.T) {
+	errFile := filepath.Join(t.TempDir(), "err.log")
+
+	l, err := conflog.New(conflog.WithRateLimit("info=3,error=1"), conflog.WithErrFile(errFile))
+	if err != nil {
+		t.Fatalf("failed to new: %v", err)
+	}
+	defer l.Close()
+
+	buf := new(bytes.Buffer)
+	l.SetOutput(buf)
+
+	for i := 0; i < 5; i++ {
+		l.WithField("i", i).Info("hello")
+		l.WithField("i", i).Error("oops")
+	}
+
+	if got, want := bytes.Count(buf.Bytes(), []byte("\n")), 4; got != want {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+
+	if got, want := l.Sampled(), uint64(6); got != want {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+
+	content, err := os.ReadFile(errFile)
+	if err != nil {
+		t.Fatalf("failed to read file: %v", err)
+	}
+
+	if got, want := bytes.Count(content, []byte("\n")), 5; got != want {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+}
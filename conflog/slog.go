@@ -0,0 +1,219 @@
+package conflog
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"sort"
+
+	"github.com/sirupsen/logrus"
+)
+
+type callerKey struct{}
+
+// Handler is a slog.Handler which writes records through the logrus logger
+// of a Logger, so that records are formatted, routed, sampled and hooked
+// as configured by the fields of the Logger, e.g. Format, Level, File,
+// ErrFile and ReportCaller. The attributes in groups are flattened into
+// fields with dotted keys.
+type Handler struct {
+	l      *Logger
+	fields logrus.Fields
+	prefix string
+}
+
+// Handler returns a slog.Handler which writes through l.
+func (l *Logger) Handler() *Handler {
+	return &Handler{l: l, fields: logrus.Fields{}}
+}
+
+// Slog returns a slog.Logger which writes through l, so that the call sites
+// can be migrated from logrus to slog incrementally.
+func (l *Logger) Slog() *slog.Logger {
+	return slog.New(l.Handler())
+}
+
+func (h *Handler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.l.Logger.IsLevelEnabled(logrusLevel(level))
+}
+
+func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	fields := make(logrus.Fields, len(h.fields)+r.NumAttrs())
+	for k, v := range h.fields {
+		fields[k] = v
+	}
+
+	r.Attrs(func(attr slog.Attr) bool {
+		addAttr(fields, h.prefix, attr)
+
+		return true
+	})
+
+	// The caller found by logrus is the Handler itself, so the caller of the
+	// record is carried to the router, which reports it instead.
+	if r.PC != 0 && h.l.Logger.ReportCaller {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		ctx = context.WithValue(ctx, callerKey{}, &frame)
+	}
+
+	entry := logrus.NewEntry(h.l.Logger).WithContext(ctx).WithFields(fields)
+	if !r.Time.IsZero() {
+		entry = entry.WithTime(r.Time)
+	}
+
+	entry.Log(logrusLevel(r.Level), r.Message)
+
+	return nil
+}
+
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := make(logrus.Fields, len(h.fields)+len(attrs))
+	for k, v := range h.fields {
+		fields[k] = v
+	}
+
+	for _, attr := range attrs {
+		addAttr(fields, h.prefix, attr)
+	}
+
+	return &Handler{l: h.l, fields: fields, prefix: h.prefix}
+}
+
+func (h *Handler) WithGroup(name string) slog.Handler {
+	if len(name) == 0 {
+		return h
+	}
+
+	return &Handler{l: h.l, fields: h.fields, prefix: h.prefix + name + "."}
+}
+
+// addAttr adds attr to fields with key prefixed, groups are flattened.
+func addAttr(fields logrus.Fields, prefix string, attr slog.Attr) {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return
+	}
+
+	if attr.Value.Kind() == slog.KindGroup {
+		if len(attr.Key) != 0 {
+			prefix += attr.Key + "."
+		}
+
+		for _, a := range attr.Value.Group() {
+			addAttr(fields, prefix, a)
+		}
+
+		return
+	}
+
+	fields[prefix+attr.Key] = attr.Value.Any()
+}
+
+// callerFromContext returns the caller carried by Handler.
+func callerFromContext(ctx context.Context) (*runtime.Frame, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+
+	frame, ok := ctx.Value(callerKey{}).(*runtime.Frame)
+
+	return frame, ok
+}
+
+// SlogHook is a logrus.Hook which sends entries to a slog.Handler, so that
+// the entries of the call sites not migrated yet reach the slog pipeline.
+// Use SetOutput(io.Discard) to stop writing them to the main output, and
+// never send them to a Handler of the same Logger, which loops forever.
+type SlogHook struct {
+	Handler slog.Handler
+}
+
+// NewSlogHook creates a SlogHook which sends entries to handler.
+func NewSlogHook(handler slog.Handler) *SlogHook {
+	return &SlogHook{Handler: handler}
+}
+
+func (h *SlogHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *SlogHook) Fire(entry *logrus.Entry) error {
+	ctx := entry.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	level := slogLevel(entry.Level)
+	if !h.Handler.Enabled(ctx, level) {
+		return nil
+	}
+
+	var pc uintptr
+	if entry.HasCaller() {
+		pc = entry.Caller.PC
+	}
+
+	r := slog.NewRecord(entry.Time, level, entry.Message, pc)
+
+	keys := make([]string, 0, len(entry.Data))
+	for k := range entry.Data {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		r.AddAttrs(slog.Any(k, entry.Data[k]))
+	}
+
+	if err := h.Handler.Handle(ctx, r); err != nil {
+		return fmt.Errorf("failed to handle: %w", err)
+	}
+
+	return nil
+}
+
+// logrusLevel maps level of slog to logrus, the levels above error are
+// mapped to error, since fatal and panic of logrus exit and panic.
+func logrusLevel(level slog.Level) logrus.Level {
+	switch {
+	case level < slog.LevelDebug:
+		return logrus.TraceLevel
+	case level < slog.LevelInfo:
+		return logrus.DebugLevel
+	case level < slog.LevelWarn:
+		return logrus.InfoLevel
+	case level < slog.LevelError:
+		return logrus.WarnLevel
+	}
+
+	return logrus.ErrorLevel
+}
+
+// slogLevel maps level of logrus to slog, trace is below debug, fatal and
+// panic are above error, with the same spacing as slog.
+func slogLevel(level logrus.Level) slog.Level {
+	switch level {
+	case logrus.TraceLevel:
+		return slog.LevelDebug - 4
+	case logrus.DebugLevel:
+		return slog.LevelDebug
+	case logrus.InfoLevel:
+		return slog.LevelInfo
+	case logrus.WarnLevel:
+		return slog.LevelWarn
+	case logrus.ErrorLevel:
+		return slog.LevelError
+	case logrus.FatalLevel:
+		return slog.LevelError + 4
+	case logrus.PanicLevel:
+		return slog.LevelError + 8
+	}
+
+	return slog.LevelInfo
+}
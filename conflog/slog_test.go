@@ -0,0 +1,86 @@
+package conflog_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/rbee3u/gohelp/conflog"
+	"github.com/rbee3u/gohelp/conflog/conflogtest"
+	"github.com/sirupsen/logrus"
+)
+
+func TestHandler(t *testing.T) {
+	l, r := conflogtest.New(t, conflog.WithLevel("info"))
+
+	logger := l.Slog().With("service", "api").WithGroup("req")
+
+	logger.Debug("hidden")
+	logger.Info("served", "status", 200, slog.Group("user", "id", 7))
+	logger.Error("failed")
+
+	r.AssertNotContains(t, "hidden")
+	r.AssertCount(t, logrus.InfoLevel, 1)
+	r.AssertCount(t, logrus.ErrorLevel, 1)
+	r.AssertField(t, "service", "api")
+	r.AssertField(t, "req.status", int64(200))
+	r.AssertField(t, "req.user.id", int64(7))
+}
+
+func TestHandlerCaller(t *testing.T) {
+	l, err := conflog.New(conflog.WithFormat("json"), conflog.WithReportCaller("enable"))
+	if err != nil {
+		t.Fatalf("failed to new: %v", err)
+	}
+	defer l.Close()
+
+	buf := new(bytes.Buffer)
+	l.SetOutput(buf)
+
+	l.Slog().Info("hello")
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+
+	if got, want := fmt.Sprint(record["file"]), "TestHandlerCaller"; !strings.Contains(got, want) {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+}
+
+func TestSlogHook(t *testing.T) {
+	l, err := conflog.New(conflog.WithLevel("trace"))
+	if err != nil {
+		t.Fatalf("failed to new: %v", err)
+	}
+	defer l.Close()
+
+	l.SetOutput(new(bytes.Buffer))
+
+	buf := new(bytes.Buffer)
+	l.AddHook(conflog.NewSlogHook(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelInfo})))
+
+	l.Debug("hidden")
+	l.WithField("port", 8080).Warn("listening")
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+
+	if got, want := record["level"], "WARN"; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+
+	if got, want := record["msg"], "listening"; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+
+	if got, want := record["port"], float64(8080); got != want {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+}
@@ -0,0 +1,194 @@
+package conflog
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// SyslogWriter is a LevelWriter which sends entries as RFC 5424 messages.
+// The network is one of "udp", "tcp" and "unix", messages over tcp are
+// framed by octet counting as RFC 6587 describes.
+type SyslogWriter struct {
+	network  string
+	address  string
+	facility int
+	hostname string
+	appName  string
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// NewSyslogWriter creates a SyslogWriter from rawURL like "udp://host:514",
+// "tcp://host:601" or "unix:///dev/log", the connection is made lazily.
+func NewSyslogWriter(rawURL string, facility string, appName string) (*SyslogWriter, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse url: %w", err)
+	}
+
+	w := &SyslogWriter{network: u.Scheme, appName: appName}
+
+	switch u.Scheme {
+	case "udp", "tcp":
+		w.address = u.Host
+	case "unix":
+		w.address = u.Path
+	default:
+		return nil, fmt.Errorf("invalid scheme: %q", u.Scheme)
+	}
+
+	if w.facility, err = parseFacility(facility); err != nil {
+		return nil, err
+	}
+
+	if w.hostname, err = os.Hostname(); err != nil || len(w.hostname) == 0 {
+		w.hostname = "-"
+	}
+
+	return w, nil
+}
+
+func (w *SyslogWriter) Write(p []byte) (int, error) {
+	return w.WriteLevel(logrus.InfoLevel, p)
+}
+
+func (w *SyslogWriter) WriteLevel(level logrus.Level, p []byte) (int, error) {
+	msg := bytes.TrimRight(p, "\n")
+
+	buf := new(bytes.Buffer)
+	_, _ = fmt.Fprintf(buf, "<%d>1 %s %s %s %d - - ",
+		w.facility*8+syslogSeverity(level), time.Now().Format(time.RFC3339Nano),
+		w.hostname, nilValue(w.appName), os.Getpid())
+	buf.Write(msg)
+
+	frame := buf.Bytes()
+	if w.network == "tcp" {
+		frame = append([]byte(strconv.Itoa(len(frame))+" "), frame...)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	// Retry once with a new connection, since the old one may be broken.
+	for retry := 0; ; retry++ {
+		if w.conn == nil {
+			if err := w.dial(); err != nil {
+				return 0, err
+			}
+		}
+
+		_, err := w.conn.Write(frame)
+		if err == nil {
+			return len(p), nil
+		}
+
+		_ = w.conn.Close()
+		w.conn = nil
+
+		if retry > 0 {
+			return 0, fmt.Errorf("failed to write: %w", err)
+		}
+	}
+}
+
+func (w *SyslogWriter) dial() error {
+	if w.network != "unix" {
+		conn, err := net.Dial(w.network, w.address)
+		if err != nil {
+			return fmt.Errorf("failed to dial: %w", err)
+		}
+
+		w.conn = conn
+
+		return nil
+	}
+
+	// The /dev/log is usually a datagram socket, but some are stream ones.
+	conn, err := net.Dial("unixgram", w.address)
+	if err != nil {
+		if conn, err = net.Dial("unix", w.address); err != nil {
+			return fmt.Errorf("failed to dial: %w", err)
+		}
+	}
+
+	w.conn = conn
+
+	return nil
+}
+
+func (w *SyslogWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn == nil {
+		return nil
+	}
+
+	err := w.conn.Close()
+	w.conn = nil
+
+	if err != nil {
+		return fmt.Errorf("failed to close conn: %w", err)
+	}
+
+	return nil
+}
+
+// syslogSeverity maps level to the severity of syslog, which is also used
+// as the priority of journald.
+func syslogSeverity(level logrus.Level) int {
+	switch level {
+	case logrus.PanicLevel:
+		return 1
+	case logrus.FatalLevel:
+		return 2
+	case logrus.ErrorLevel:
+		return 3
+	case logrus.WarnLevel:
+		return 4
+	case logrus.InfoLevel:
+		return 6
+	case logrus.DebugLevel, logrus.TraceLevel:
+		return 7
+	}
+
+	return 5
+}
+
+func parseFacility(facility string) (int, error) {
+	switch facility {
+	case "kern":
+		return 0, nil
+	case "user", "":
+		return 1, nil
+	case "mail":
+		return 2, nil
+	case "daemon":
+		return 3, nil
+	case "auth":
+		return 4, nil
+	case "syslog":
+		return 5, nil
+	case "local0", "local1", "local2", "local3", "local4", "local5", "local6", "local7":
+		return 16 + int(facility[5]-'0'), nil
+	}
+
+	return 0, fmt.Errorf("invalid facility: %q", facility)
+}
+
+func nilValue(s string) string {
+	if len(s) == 0 {
+		return "-"
+	}
+
+	return s
+}
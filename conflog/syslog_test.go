@@ -0,0 +1,188 @@
+package conflog_test
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rbee3u/gohelp/conflog"
+)
+
+func TestSyslogUDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer pc.Close()
+
+	l, err := conflog.New(
+		conflog.WithSyslog("udp://"+pc.LocalAddr().String()),
+		conflog.WithSyslogFacility("local0"),
+		conflog.WithAppName("demo"),
+	)
+	if err != nil {
+		t.Fatalf("failed to new: %v", err)
+	}
+	defer l.Close()
+
+	l.SetOutput(new(bytes.Buffer))
+	l.Warn("hello")
+
+	_ = pc.SetReadDeadline(time.Now().Add(time.Second))
+
+	buf := make([]byte, 1024)
+
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("failed to read: %v", err)
+	}
+
+	// The PRI of local0.warning is 16*8+4.
+	re := regexp.MustCompile(`^<132>1 \S+ \S+ demo \d+ - - time=.* level=warning msg=hello$`)
+	if got := string(buf[:n]); !re.MatchString(got) {
+		t.Errorf("unexpected message: %q", got)
+	}
+}
+
+func TestSyslogTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer ln.Close()
+
+	w, err := conflog.NewSyslogWriter("tcp://"+ln.Addr().String(), "user", "demo")
+	if err != nil {
+		t.Fatalf("failed to new syslog writer: %v", err)
+	}
+	defer w.Close()
+
+	if _, err := w.Write([]byte("hello\n")); err != nil {
+		t.Fatalf("failed to write: %v", err)
+	}
+
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatalf("failed to accept: %v", err)
+	}
+	defer conn.Close()
+
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+
+	// The frame is prefixed with its octet count, see RFC 6587.
+	reader := bufio.NewReader(conn)
+
+	count, err := reader.ReadString(' ')
+	if err != nil {
+		t.Fatalf("failed to read: %v", err)
+	}
+
+	n, err := strconv.Atoi(strings.TrimSuffix(count, " "))
+	if err != nil {
+		t.Fatalf("failed to parse octet count: %v", err)
+	}
+
+	frame := make([]byte, n)
+	if _, err := io.ReadFull(reader, frame); err != nil {
+		t.Fatalf("failed to read: %v", err)
+	}
+
+	fields := strings.SplitN(string(frame), " ", 8)
+	if len(fields) != 8 || fields[0] != "<14>1" || fields[3] != "demo" || fields[7] != "hello" {
+		t.Errorf("unexpected frame: %q", frame)
+	}
+
+	for _, rawURL := range []string{"http://host", "::"} {
+		if _, err := conflog.NewSyslogWriter(rawURL, "user", "demo"); err == nil {
+			t.Errorf("expect err of %q not to be nil", rawURL)
+		}
+	}
+
+	if _, err := conflog.NewSyslogWriter("udp://host:514", "x", "demo"); err == nil {
+		t.Errorf("expect err not to be nil")
+	}
+}
+
+func TestJournald(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "journal.sock")
+
+	pc, err := net.ListenPacket("unixgram", socket)
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer pc.Close()
+
+	l, err := conflog.New(
+		conflog.WithJournald("enable"),
+		conflog.WithJournaldSocket(socket),
+		conflog.WithAppName("demo"),
+	)
+	if err != nil {
+		t.Fatalf("failed to new: %v", err)
+	}
+	defer l.Close()
+
+	l.SetOutput(new(bytes.Buffer))
+	l.Error("multi\nline")
+
+	_ = pc.SetReadDeadline(time.Now().Add(time.Second))
+
+	buf := make([]byte, 1024)
+
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("failed to read: %v", err)
+	}
+
+	got := string(buf[:n])
+	if !strings.HasPrefix(got, "PRIORITY=3\nSYSLOG_IDENTIFIER=demo\nMESSAGE=time=") {
+		t.Errorf("unexpected datagram: %q", got)
+	}
+
+	w := conflog.NewJournaldWriter(socket, "")
+	defer w.Close()
+
+	if _, err := w.Write([]byte("multi\nline\n")); err != nil {
+		t.Fatalf("failed to write: %v", err)
+	}
+
+	if n, _, err = pc.ReadFrom(buf); err != nil {
+		t.Fatalf("failed to read: %v", err)
+	}
+
+	want := "PRIORITY=6\nMESSAGE\n\x0a\x00\x00\x00\x00\x00\x00\x00multi\nline\n"
+	if got := string(buf[:n]); got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+
+	// journald restarts, the broken connection is replaced.
+	_ = pc.Close()
+	_ = os.Remove(socket)
+
+	if pc, err = net.ListenPacket("unixgram", socket); err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer pc.Close()
+
+	if _, err := w.Write([]byte("again\n")); err != nil {
+		t.Fatalf("failed to write: %v", err)
+	}
+
+	_ = pc.SetReadDeadline(time.Now().Add(time.Second))
+
+	if n, _, err = pc.ReadFrom(buf); err != nil {
+		t.Fatalf("failed to read: %v", err)
+	}
+
+	if got, want := string(buf[:n]), "PRIORITY=6\nMESSAGE=again\n"; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+}
@@ -0,0 +1,59 @@
+package epkg
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// Link is a layer in the chain of an error.
+type Link struct {
+	// Message is the message of the layer, without the messages of the
+	// layers it wraps.
+	Message string
+	// Location is where the layer is created, like "function:line", it is
+	// recorded by File, or empty if there is none.
+	Location string
+	// Code is the code of the layer if it has a "Code() int" method, like
+	// the errors of status, or zero otherwise.
+	Code int
+}
+
+// Chain splits err into links from the outermost to the innermost, the
+// layers which add no message, like those of File and Stack, are merged
+// into the links they wrap.
+func Chain(err error) []Link {
+	var (
+		links    []Link
+		location string
+	)
+
+	for ; err != nil; err = errors.Unwrap(err) {
+		switch e := err.(type) { //nolint:errorlint // each layer is inspected on purpose
+		case *fileError:
+			location = e.pre + ":" + strconv.Itoa(e.num)
+
+			continue
+		case *stackError:
+			continue
+		}
+
+		link := Link{Message: err.Error(), Location: location}
+		if coder, ok := err.(interface{ Code() int }); ok {
+			link.Code = coder.Code()
+		}
+
+		if inner := errors.Unwrap(err); inner != nil {
+			if link.Message == inner.Error() && len(link.Location) == 0 && link.Code == 0 {
+				continue
+			}
+
+			link.Message = strings.TrimSuffix(link.Message, ": "+inner.Error())
+		}
+
+		links = append(links, link)
+		location = ""
+	}
+
+	return links
+}
@@ -0,0 +1,60 @@
+package epkg_test
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rbee3u/gohelp/epkg"
+)
+
+func TestChain(t *testing.T) {
+	err := epkg.ErrorWL("root")
+	err = fmt.Errorf("middle: %w", err)
+	err = epkg.Stack(epkg.WrapWL(err, "outer"))
+
+	links := epkg.Chain(err)
+
+	messages := make([]string, 0, len(links))
+	for _, link := range links {
+		messages = append(messages, link.Message)
+	}
+
+	if got, want := messages, []string{"outer", "middle", "root"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+
+	if !strings.HasSuffix(links[0].Location, "epkg_test.TestChain:15") {
+		t.Errorf("unexpected location: %q", links[0].Location)
+	}
+
+	if len(links[1].Location) != 0 {
+		t.Errorf("unexpected location: %q", links[1].Location)
+	}
+
+	if !strings.HasSuffix(links[2].Location, "epkg_test.TestChain:13") {
+		t.Errorf("unexpected location: %q", links[2].Location)
+	}
+
+	stack := epkg.StackTrace(err)
+	if len(stack) == 0 || !strings.HasPrefix(stack[0], "github.com/rbee3u/gohelp/epkg_test.TestChain ") {
+		t.Errorf("unexpected stack: %q", stack)
+	}
+
+	if got := epkg.StackTrace(epkg.Error("x")); got != nil {
+		t.Errorf("got: %q, want: nil", got)
+	}
+}
+
+func TestStackEmpty(t *testing.T) {
+	err := epkg.StackWithSkip(epkg.Error("x"), 1000)
+
+	if got := epkg.StackTrace(err); len(got) != 0 {
+		t.Errorf("got: %q, want: empty", got)
+	}
+
+	if got, want := fmt.Sprintf("%+v", err), "x"; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+}
@@ -0,0 +1,90 @@
+package epkg
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"strconv"
+)
+
+const maxStackDepth = 64
+
+func Stack(err error) error {
+	return StackWithSkip(err, 1)
+}
+
+// StackWithSkip records the stack of the caller into err, skip is the number
+// of frames to skip, like FileWithSkip.
+func StackWithSkip(err error, skip int) error {
+	if err == nil {
+		return nil
+	}
+
+	pcs := make([]uintptr, maxStackDepth)
+	n := runtime.Callers(skip+2, pcs)
+
+	return &stackError{err: err, pcs: pcs[:n]}
+}
+
+type stackError struct {
+	err error
+	pcs []uintptr
+}
+
+func (e *stackError) Error() string {
+	return e.err.Error()
+}
+
+func (e *stackError) Unwrap() error {
+	return e.err
+}
+
+func (e *stackError) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			_, _ = fmt.Fprintf(s, "%+v", e.err)
+
+			for _, frame := range e.frames() {
+				_, _ = io.WriteString(s, "\n\t"+frame)
+			}
+
+			return
+		}
+
+		fallthrough
+	case 's':
+		_, _ = io.WriteString(s, e.Error())
+	case 'q':
+		_, _ = fmt.Fprintf(s, "%q", e.Error())
+	}
+}
+
+func (e *stackError) frames() []string {
+	frames := runtime.CallersFrames(e.pcs)
+
+	lines := make([]string, 0, len(e.pcs))
+
+	// Next returns a zero frame if there is no pc at all.
+	for more := len(e.pcs) != 0; more; {
+		var frame runtime.Frame
+
+		frame, more = frames.Next()
+		lines = append(lines, frame.Function+" "+frame.File+":"+strconv.Itoa(frame.Line))
+	}
+
+	return lines
+}
+
+// StackTrace returns the frames of the outermost stack recorded in the chain
+// of err, each of which is like "function file:line", or nil if there is no
+// stack recorded.
+func StackTrace(err error) []string {
+	var se *stackError
+	if errors.As(err, &se) {
+		return se.frames()
+	}
+
+	return nil
+}
@@ -1,10 +1,9 @@
 module github.com/rbee3u/gohelp
 
-go 1.17
+go 1.21
 
 require (
 	github.com/gin-gonic/gin v1.7.4
-	github.com/rifflock/lfshook v0.0.0-20180920164130-b9218ef580f5
 	github.com/sirupsen/logrus v1.8.1
 	github.com/thejerf/suture/v4 v4.0.1
 )
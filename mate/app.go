@@ -7,19 +7,97 @@ import (
 )
 
 type App struct {
-	prefix string
+	prefix     string
+	profileKey string
+	overlays   map[string][]string
 }
 
-func NewApp(prefix string) *App {
+type AppOption func(*App)
+
+// WithProfileKey changes the key of the variable which selects the active
+// profiles, it is "PROFILE" by default, so that the variable of an app with
+// prefix "MYAPP_" is "MYAPP_PROFILE". Multiple profiles can be activated by
+// separating them with commas, the later ones take precedence.
+func WithProfileKey(profileKey string) AppOption {
+	return func(app *App) {
+		app.profileKey = strings.ToUpper(profileKey)
+	}
+}
+
+// WithProfile registers an overlay environ for the profile named name, it
+// takes effect only when the profile is active. Overlays take precedence
+// over the profile defaults, while the environ passed to Unmarshal always
+// takes precedence over overlays.
+func WithProfile(name string, overlay []string) AppOption {
+	return func(app *App) {
+		if app.overlays == nil {
+			app.overlays = map[string][]string{}
+		}
+
+		app.overlays[name] = append(app.overlays[name], overlay...)
+	}
+}
+
+func NewApp(prefix string, opts ...AppOption) *App {
 	prefix = strings.ReplaceAll(prefix, "-", "_")
 
-	return &App{prefix: strings.ToUpper(prefix)}
+	app := &App{prefix: strings.ToUpper(prefix), profileKey: "PROFILE"}
+
+	for _, opt := range opts {
+		opt(app)
+	}
+
+	return app
 }
 
+// ActiveProfiles returns the profiles selected by environ, in order.
+func (app *App) ActiveProfiles(environ []string) []string {
+	ev := NewEnvVarsFromEnviron(app.prefix, environ).Get(app.profileKey)
+	if ev == nil {
+		return nil
+	}
+
+	var profiles []string
+
+	for _, profile := range strings.Split(ev.Value, ",") {
+		if profile = strings.TrimSpace(profile); len(profile) != 0 {
+			profiles = append(profiles, profile)
+		}
+	}
+
+	return profiles
+}
+
+// envVars merges the overlays of the active profiles and environ, in a
+// deterministic order, into the variables to be decoded.
+func (app *App) envVars(environ []string) *EnvVars {
+	profiles := app.ActiveProfiles(environ)
+
+	var merged []string
+	for _, profile := range profiles {
+		merged = append(merged, app.overlays[profile]...)
+	}
+
+	merged = append(merged, environ...)
+
+	evs := NewEnvVarsFromEnviron(app.prefix, merged)
+	evs.Profiles = profiles
+
+	return evs
+}
+
+func (app *App) setProfileKey(evs *EnvVars, profiles []string) {
+	if len(profiles) != 0 {
+		evs.Set(&EnvVar{Key: app.profileKey, Value: strings.Join(profiles, ",")})
+	}
+}
+
+// ScanDefaults dumps the defaults of v, the nil pointers in v are allocated
+// so that the defaults of optional sub-configs are dumped too.
 func (app *App) ScanDefaults(v interface{}) (*EnvVars, error) {
 	evs := NewEnvVars(app.prefix)
 
-	if err := evs.To(v); err != nil {
+	if err := evs.to(v, true); err != nil {
 		return nil, fmt.Errorf("failed to v: %w", err)
 	}
 
@@ -31,7 +109,7 @@ func (app *App) ScanDefaults(v interface{}) (*EnvVars, error) {
 }
 
 func (app *App) Unmarshal(environ []string, v interface{}) error {
-	evs := NewEnvVarsFromEnviron(app.prefix, environ)
+	evs := app.envVars(environ)
 
 	if err := evs.To(v); err != nil {
 		return fmt.Errorf("failed to v: %w", err)
@@ -52,6 +130,11 @@ func triggerInitials(v interface{}) error {
 		for i := 0; i < rv.NumField(); i++ {
 			value := rv.Field(i)
 
+			// An absent sub-config is left nil, there is nothing to initialize.
+			if value.Kind() == reflect.Ptr && value.IsNil() {
+				continue
+			}
+
 			if conf, ok := value.Interface().(interface{ Initialize() error }); ok {
 				if err := conf.Initialize(); err != nil {
 					return fmt.Errorf("failed to initialize: %w", err)
@@ -69,11 +152,65 @@ func triggerInitials(v interface{}) error {
 	return nil
 }
 
-func (app *App) View(v interface{}) (string, error) {
+// Normalize decodes environ into a fresh copy of v's type and encodes it
+// back, so that the result only contains the keys known to the schema of v,
+// with defaults filled in for the missing ones.
+func (app *App) Normalize(environ []string, v interface{}) (*EnvVars, error) {
+	rt := reflect.TypeOf(v)
+	if rt == nil || rt.Kind() != reflect.Ptr {
+		return nil, fmt.Errorf("invalid v: %T is not a pointer", v)
+	}
+
+	rv := reflect.New(rt.Elem())
+
+	src := app.envVars(environ)
+	if err := src.To(rv); err != nil {
+		return nil, fmt.Errorf("failed to v: %w", err)
+	}
+
+	evs := NewEnvVars(app.prefix)
+	if err := evs.From(rv); err != nil {
+		return nil, fmt.Errorf("failed from v: %w", err)
+	}
+
+	app.setProfileKey(evs, src.Profiles)
+
+	return evs, nil
+}
+
+// Diff compares two environments against the schema of v, the values
+// which are equal to the defaults of v are marked in the result.
+func (app *App) Diff(v interface{}, oldEnviron, newEnviron []string) ([]*EnvVarDiff, error) {
+	defaults, err := app.Normalize(nil, v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to normalize defaults: %w", err)
+	}
+
+	oldEvs, err := app.Normalize(oldEnviron, v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to normalize old environ: %w", err)
+	}
+
+	newEvs, err := app.Normalize(newEnviron, v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to normalize new environ: %w", err)
+	}
+
+	diffs := oldEvs.Diff(newEvs)
+	MarkDefaults(diffs, defaults)
+
+	return diffs, nil
+}
+
+// View dumps v as sorted variables, including the profiles if any, e.g. the
+// ones returned by ActiveProfiles for the environ v is unmarshaled from.
+func (app *App) View(v interface{}, profiles ...string) (string, error) {
 	evs := NewEnvVars(app.prefix)
 	if err := evs.From(v); err != nil {
 		return "", fmt.Errorf("failed from v: %w", err)
 	}
 
+	app.setProfileKey(evs, profiles)
+
 	return evs.View(), nil
 }
@@ -0,0 +1,95 @@
+package mate
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// ErrDrift is returned by RunDiff when the two environments differ.
+var ErrDrift = errors.New("environments drift")
+
+const (
+	// SourceDefaults refers to the defaults of the schema.
+	SourceDefaults = "@defaults"
+	// SourceEnviron refers to the environ of current process.
+	SourceEnviron = "@environ"
+)
+
+// RunDiff implements a small command which compares two environments
+// against the schema of v, it is meant to be mounted as a sub command
+// of the application, for example:
+//
+//	myapp config-diff [-all] staging.env production.env
+//	myapp config-diff @defaults @environ
+//
+// Each source is either a .env file, SourceDefaults or SourceEnviron.
+// ErrDrift is returned if any key is added, removed or changed.
+func (app *App) RunDiff(v interface{}, args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("config-diff", flag.ContinueOnError)
+	fs.SetOutput(stdout)
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(fs.Output(), "usage: config-diff [-all] OLD NEW\n")
+		fs.PrintDefaults()
+	}
+
+	all := fs.Bool("all", false, "also print the unchanged keys")
+
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("failed to parse args: %w", err)
+	}
+
+	if fs.NArg() != 2 {
+		fs.Usage()
+
+		return fmt.Errorf("invalid number of sources: %d != 2", fs.NArg())
+	}
+
+	oldEnviron, err := loadSource(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("failed to load old source: %w", err)
+	}
+
+	newEnviron, err := loadSource(fs.Arg(1))
+	if err != nil {
+		return fmt.Errorf("failed to load new source: %w", err)
+	}
+
+	diffs, err := app.Diff(v, oldEnviron, newEnviron)
+	if err != nil {
+		return fmt.Errorf("failed to diff: %w", err)
+	}
+
+	drift := false
+
+	for _, d := range diffs {
+		if d.Kind != DiffUnchanged {
+			drift = true
+		} else if !*all {
+			continue
+		}
+
+		if _, err := fmt.Fprintln(stdout, d); err != nil {
+			return fmt.Errorf("failed to print: %w", err)
+		}
+	}
+
+	if drift {
+		return ErrDrift
+	}
+
+	return nil
+}
+
+func loadSource(source string) ([]string, error) {
+	switch source {
+	case SourceDefaults:
+		return nil, nil
+	case SourceEnviron:
+		return os.Environ(), nil
+	}
+
+	return LoadEnvFile(source)
+}
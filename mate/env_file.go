@@ -0,0 +1,69 @@
+package mate
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ReadEnvFile parses the content of a .env file into environ form, blank
+// lines and comments are skipped, an optional "export " is allowed before
+// each key, and values may be single or double quoted.
+func ReadEnvFile(r io.Reader) ([]string, error) {
+	var environ []string
+
+	scanner := bufio.NewScanner(r)
+	for num := 1; scanner.Scan(); num++ {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		line = strings.TrimPrefix(line, "export ")
+
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid line %d: missing '='", num)
+		}
+
+		key, value := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+
+		if len(value) >= 2 {
+			switch value[0] {
+			case '"':
+				x, err := strconv.Unquote(value)
+				if err != nil {
+					return nil, fmt.Errorf("invalid line %d: %w", num, err)
+				}
+
+				value = x
+			case '\'':
+				if value[len(value)-1] == '\'' {
+					value = value[1 : len(value)-1]
+				}
+			}
+		}
+
+		environ = append(environ, key+"="+value)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to scan: %w", err)
+	}
+
+	return environ, nil
+}
+
+// LoadEnvFile reads the .env file name in environ form, see ReadEnvFile.
+func LoadEnvFile(name string) ([]string, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	return ReadEnvFile(file)
+}
@@ -35,8 +35,9 @@ func NewEnvVarsFromEnviron(prefix string, environ []string) *EnvVars {
 }
 
 type EnvVars struct {
-	Prefix string
-	Dict   map[string]*EnvVar
+	Prefix   string
+	Dict     map[string]*EnvVar
+	Profiles []string
 }
 
 type EnvVar struct {
@@ -87,6 +88,23 @@ func (evs *EnvVars) Get(key string) *EnvVar {
 	return evs.Dict[strings.ToUpper(key)]
 }
 
+// HasPrefix reports whether there is any variable whose key is equal to
+// key, or is under the path of key.
+func (evs *EnvVars) HasPrefix(key string) bool {
+	k := strings.ToUpper(key)
+	if len(k) == 0 {
+		return len(evs.Dict) != 0
+	}
+
+	for dk := range evs.Dict {
+		if dk == k || strings.HasPrefix(dk, k+"_") {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (evs *EnvVars) View() string {
 	dict := map[string]string{}
 	for _, ev := range evs.Dict {
@@ -0,0 +1,126 @@
+package mate
+
+import (
+	"sort"
+	"strings"
+)
+
+type DiffKind int
+
+const (
+	DiffUnchanged DiffKind = iota
+	DiffAdded
+	DiffRemoved
+	DiffChanged
+)
+
+func (k DiffKind) String() string {
+	switch k {
+	case DiffUnchanged:
+		return "unchanged"
+	case DiffAdded:
+		return "added"
+	case DiffRemoved:
+		return "removed"
+	case DiffChanged:
+		return "changed"
+	}
+
+	return "unknown"
+}
+
+type EnvVarDiff struct {
+	Key          string
+	Kind         DiffKind
+	Old          *EnvVar
+	New          *EnvVar
+	OldIsDefault bool
+	NewIsDefault bool
+}
+
+func (d *EnvVarDiff) String() string {
+	buf := new(strings.Builder)
+
+	writeValue := func(ev *EnvVar, isDefault bool) {
+		buf.WriteString(ev.Value)
+
+		if isDefault {
+			buf.WriteString(" (default)")
+		}
+	}
+
+	switch d.Kind {
+	case DiffUnchanged:
+		buf.WriteString("  " + d.Key + "=")
+		writeValue(d.New, d.NewIsDefault)
+	case DiffAdded:
+		buf.WriteString("+ " + d.Key + "=")
+		writeValue(d.New, d.NewIsDefault)
+	case DiffRemoved:
+		buf.WriteString("- " + d.Key + "=")
+		writeValue(d.Old, d.OldIsDefault)
+	case DiffChanged:
+		buf.WriteString("~ " + d.Key + "=")
+		writeValue(d.Old, d.OldIsDefault)
+		buf.WriteString(" -> ")
+		writeValue(d.New, d.NewIsDefault)
+	}
+
+	return buf.String()
+}
+
+// Diff compares evs (the old side) with other (the new side) key by
+// key, the result is sorted by key and includes unchanged keys.
+func (evs *EnvVars) Diff(other *EnvVars) []*EnvVarDiff {
+	keys := make(map[string]bool)
+	for key := range evs.Dict {
+		keys[key] = true
+	}
+
+	for key := range other.Dict {
+		keys[key] = true
+	}
+
+	diffs := make([]*EnvVarDiff, 0, len(keys))
+
+	for key := range keys {
+		d := &EnvVarDiff{Key: other.Prefix + key, Old: evs.Get(key), New: other.Get(key)}
+
+		switch {
+		case d.Old == nil:
+			d.Kind = DiffAdded
+		case d.New == nil:
+			d.Kind = DiffRemoved
+		case d.Old.Value != d.New.Value:
+			d.Kind = DiffChanged
+		default:
+			d.Kind = DiffUnchanged
+		}
+
+		diffs = append(diffs, d)
+	}
+
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].Key < diffs[j].Key
+	})
+
+	return diffs
+}
+
+// MarkDefaults flags the values of diffs which are equal to defaults.
+func MarkDefaults(diffs []*EnvVarDiff, defaults *EnvVars) {
+	isDefault := func(ev *EnvVar) bool {
+		if ev == nil {
+			return false
+		}
+
+		dv := defaults.Get(ev.Key)
+
+		return dv != nil && dv.Value == ev.Value
+	}
+
+	for _, d := range diffs {
+		d.OldIsDefault = isDefault(d.Old)
+		d.NewIsDefault = isDefault(d.New)
+	}
+}
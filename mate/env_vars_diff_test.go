@@ -0,0 +1,113 @@
+package mate_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rbee3u/gohelp/mate"
+)
+
+type diffConf struct {
+	Host string `env:""`
+	Port int    `env:""`
+	Tags []string
+}
+
+func (c *diffConf) SetDefaults() error {
+	if len(c.Host) == 0 {
+		c.Host = "localhost"
+	}
+
+	if c.Port == 0 {
+		c.Port = 80
+	}
+
+	return nil
+}
+
+func TestAppDiff(t *testing.T) {
+	app := mate.NewApp("MYAPP_")
+
+	diffs, err := app.Diff(&diffConf{},
+		[]string{"MYAPP_PORT=8080", "MYAPP_TAGS_0=a", "MYAPP_UNKNOWN=x"},
+		[]string{"MYAPP_HOST=example.com", "MYAPP_PORT=80", "OTHER_PORT=1"},
+	)
+	if err != nil {
+		t.Fatalf("failed to diff: %v", err)
+	}
+
+	lines := make([]string, 0, len(diffs))
+	for _, d := range diffs {
+		lines = append(lines, d.String())
+	}
+
+	got := strings.Join(lines, "\n")
+	want := strings.Join([]string{
+		"~ MYAPP_HOST=localhost (default) -> example.com",
+		"~ MYAPP_PORT=8080 -> 80 (default)",
+		"- MYAPP_TAGS_0=a",
+	}, "\n")
+
+	if got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+}
+
+func TestReadEnvFile(t *testing.T) {
+	content := strings.Join([]string{
+		"# comment",
+		"",
+		"export A=1",
+		`B="x y\n"`,
+		"C='z'",
+		"D=",
+	}, "\n")
+
+	environ, err := mate.ReadEnvFile(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("failed to read env file: %v", err)
+	}
+
+	got, want := strings.Join(environ, "|"), "A=1|B=x y\n|C=z|D="
+	if got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+
+	if _, err := mate.ReadEnvFile(strings.NewReader("A")); err == nil {
+		t.Errorf("expect err not to be nil")
+	}
+}
+
+func TestAppRunDiff(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "prod.env")
+	if err := os.WriteFile(name, []byte("MYAPP_PORT=443\n"), 0o600); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	app := mate.NewApp("MYAPP_")
+	buf := new(bytes.Buffer)
+
+	err := app.RunDiff(&diffConf{}, []string{"-all", mate.SourceDefaults, name}, buf)
+	if !errors.Is(err, mate.ErrDrift) {
+		t.Errorf("got: %v, want: %v", err, mate.ErrDrift)
+	}
+
+	got, want := buf.String(), "  MYAPP_HOST=localhost (default)\n~ MYAPP_PORT=80 (default) -> 443\n"
+	if got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+
+	buf.Reset()
+
+	if err := app.RunDiff(&diffConf{}, []string{name, name}, buf); err != nil {
+		t.Errorf("expect err(%v) to be nil", err)
+	}
+
+	if got := buf.String(); got != "" {
+		t.Errorf("got: %q, want: %q", got, "")
+	}
+}
@@ -30,6 +30,15 @@ func (e *encoder) from(rv reflect.Value) error {
 	}
 
 	if rv.CanInterface() {
+		if oe, ok := rv.Interface().(optionalEncoder); ok {
+			value, present := oe.optionalValue()
+			if !present {
+				return nil
+			}
+
+			return e.from(value)
+		}
+
 		if textMarshaler, ok := rv.Interface().(encoding.TextMarshaler); ok {
 			text, err := textMarshaler.MarshalText()
 			if err != nil {
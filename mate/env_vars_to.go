@@ -9,6 +9,10 @@ import (
 )
 
 func (evs *EnvVars) To(v interface{}) error {
+	return evs.to(v, false)
+}
+
+func (evs *EnvVars) to(v interface{}, allocAll bool) error {
 	rv, ok := v.(reflect.Value)
 	if !ok {
 		rv = reflect.ValueOf(v)
@@ -18,7 +22,7 @@ func (evs *EnvVars) To(v interface{}) error {
 		return fmt.Errorf("invalid rv.Kind(): %s != %s", rv.Kind(), reflect.Ptr)
 	}
 
-	d := &decoder{evs: evs, pw: NewPathWalker()}
+	d := &decoder{evs: evs, pw: NewPathWalker(), allocAll: allocAll}
 
 	return d.to(rv)
 }
@@ -26,18 +30,35 @@ func (evs *EnvVars) To(v interface{}) error {
 type decoder struct {
 	evs *EnvVars
 	pw  *PathWalker
+	// allocAll allocates all nil pointers, so that their defaults are set.
+	allocAll bool
 }
 
 func (d *decoder) to(rv reflect.Value) error {
 	if rv.CanAddr() {
 		rp := rv.Addr()
 
+		if od, ok := rp.Interface().(optionalDecoder); ok {
+			// The defaults are decoded even if the Optional is absent.
+			return d.to(od.optionalTarget(d.evs.HasPrefix(d.pw.String())))
+		}
+
 		if defaultsSetter, ok := rp.Interface().(interface{ SetDefaults() error }); ok {
 			if err := defaultsSetter.SetDefaults(); err != nil {
 				return fmt.Errorf("failed to set defaults: %w", err)
 			}
 		}
 
+		if profileDefaultsSetter, ok := rp.Interface().(interface {
+			SetProfileDefaults(profile string) error
+		}); ok {
+			for _, profile := range d.evs.Profiles {
+				if err := profileDefaultsSetter.SetProfileDefaults(profile); err != nil {
+					return fmt.Errorf("failed to set profile defaults: %w", err)
+				}
+			}
+		}
+
 		if textUnmarshaler, ok := rp.Interface().(encoding.TextUnmarshaler); ok {
 			ev := d.evs.Get(d.pw.String())
 			if ev == nil {
@@ -45,7 +66,7 @@ func (d *decoder) to(rv reflect.Value) error {
 			}
 
 			if err := textUnmarshaler.UnmarshalText([]byte(ev.Value)); err != nil {
-				return fmt.Errorf("failed to unmarshal text: %w", err)
+				return d.decodeError(ev, rv, err)
 			}
 
 			return nil
@@ -55,6 +76,10 @@ func (d *decoder) to(rv reflect.Value) error {
 	switch rv.Kind() {
 	case reflect.Ptr:
 		if rv.IsNil() {
+			if !d.allocAll && !d.evs.HasPrefix(d.pw.String()) {
+				return nil
+			}
+
 			rv.Set(reflect.New(rv.Type().Elem()))
 		}
 
@@ -118,6 +143,12 @@ func (d *decoder) to(rv reflect.Value) error {
 				d.pw.Enter(StringPath(name))
 			}
 
+			// A nil pointer is left as it is unless some variable under its
+			// path is present, the "alloc" flag forces it to be allocated.
+			if fv := rv.Field(i); tagFlags["alloc"] && fv.Kind() == reflect.Ptr && fv.IsNil() {
+				fv.Set(reflect.New(fv.Type().Elem()))
+			}
+
 			err := d.to(rv.Field(i))
 
 			if !squash {
@@ -141,28 +172,28 @@ func (d *decoder) to(rv reflect.Value) error {
 	case reflect.Bool:
 		x, err := strconv.ParseBool(ev.Value)
 		if err != nil {
-			return fmt.Errorf("failed to parse bool: %w", err)
+			return d.decodeError(ev, rv, err)
 		}
 
 		rv.SetBool(x)
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		x, err := strconv.ParseInt(ev.Value, 10, 64)
+		x, err := strconv.ParseInt(ev.Value, 10, rv.Type().Bits())
 		if err != nil {
-			return fmt.Errorf("failed to parse int: %w", err)
+			return d.decodeError(ev, rv, err)
 		}
 
 		rv.SetInt(x)
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		x, err := strconv.ParseUint(ev.Value, 10, 64)
+		x, err := strconv.ParseUint(ev.Value, 10, rv.Type().Bits())
 		if err != nil {
-			return fmt.Errorf("failed to parse uint: %w", err)
+			return d.decodeError(ev, rv, err)
 		}
 
 		rv.SetUint(x)
 	case reflect.Float32, reflect.Float64:
-		x, err := strconv.ParseFloat(ev.Value, 64)
+		x, err := strconv.ParseFloat(ev.Value, rv.Type().Bits())
 		if err != nil {
-			return fmt.Errorf("failed to parse float: %w", err)
+			return d.decodeError(ev, rv, err)
 		}
 
 		rv.SetFloat(x)
@@ -174,3 +205,27 @@ func (d *decoder) to(rv reflect.Value) error {
 
 	return nil
 }
+
+func (d *decoder) decodeError(ev *EnvVar, rv reflect.Value, err error) error {
+	return &DecodeError{Key: ev.RealKey(d.evs.Prefix), Value: ev.Value, Type: rv.Type(), Err: err}
+}
+
+// DecodeError records a value which can not be decoded into its target.
+type DecodeError struct {
+	// Key is the full name of the variable, including the prefix.
+	Key string
+	// Value is the raw value of the variable.
+	Value string
+	// Type is the type of the target.
+	Type reflect.Type
+	// Err is the underlying error.
+	Err error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("failed to decode %s=%q into %s: %v", e.Key, e.Value, e.Type, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,41 @@
+package mate
+
+import (
+	"fmt"
+	"os"
+)
+
+// Load decodes the environ of sources into a new value of T and triggers
+// its initials, the later sources override the earlier ones, and the
+// environ of current process is used if no source is given.
+//
+// Errors caused by bad values can be inspected with errors.As and
+// *DecodeError.
+func Load[T any](app *App, sources ...[]string) (T, error) {
+	var v T
+
+	if len(sources) == 0 {
+		sources = [][]string{os.Environ()}
+	}
+
+	var environ []string
+	for _, source := range sources {
+		environ = append(environ, source...)
+	}
+
+	if err := app.Unmarshal(environ, &v); err != nil {
+		return v, fmt.Errorf("failed to unmarshal: %w", err)
+	}
+
+	return v, nil
+}
+
+// MustLoad is like Load but panics if any error occurs.
+func MustLoad[T any](app *App, sources ...[]string) T {
+	v, err := Load[T](app, sources...)
+	if err != nil {
+		panic(err)
+	}
+
+	return v
+}
@@ -0,0 +1,63 @@
+package mate_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/rbee3u/gohelp/mate"
+)
+
+type loadConf struct {
+	Port  int8 `env:""`
+	Ratio float32
+}
+
+func TestLoad(t *testing.T) {
+	app := mate.NewApp("MYAPP_")
+
+	conf, err := mate.Load[loadConf](app,
+		[]string{"MYAPP_PORT=1", "MYAPP_RATIO=0.5"},
+		[]string{"MYAPP_PORT=2"},
+	)
+	if err != nil {
+		t.Fatalf("failed to load: %v", err)
+	}
+
+	if got, want := conf, (loadConf{Port: 2, Ratio: 0.5}); got != want {
+		t.Errorf("got: %+v, want: %+v", got, want)
+	}
+}
+
+func TestLoadDecodeError(t *testing.T) {
+	app := mate.NewApp("MYAPP_")
+
+	_, err := mate.Load[loadConf](app, []string{"MYAPP_PORT=300"})
+
+	var de *mate.DecodeError
+	if !errors.As(err, &de) {
+		t.Fatalf("expect err(%v) to be *mate.DecodeError", err)
+	}
+
+	if got, want := de.Key, "MYAPP_PORT"; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+
+	if got, want := de.Value, "300"; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+
+	if got, want := de.Type, reflect.TypeOf(int8(0)); got != want {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+}
+
+func TestMustLoad(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expect MustLoad to panic")
+		}
+	}()
+
+	mate.MustLoad[loadConf](mate.NewApp("MYAPP_"), []string{"MYAPP_RATIO=x"})
+}
@@ -0,0 +1,50 @@
+package mate
+
+import (
+	"reflect"
+)
+
+// Optional wraps a value of T and records whether any variable under its
+// path was present while decoding, the Value holds the defaults anyway.
+// When encoding, an absent Optional produces no variables at all.
+type Optional[T any] struct {
+	Value   T
+	Present bool
+}
+
+// Some returns a present Optional holding v.
+func Some[T any](v T) Optional[T] {
+	return Optional[T]{Value: v, Present: true}
+}
+
+// Get returns the value and whether it is present.
+func (o Optional[T]) Get() (T, bool) {
+	return o.Value, o.Present
+}
+
+// OrElse returns the value if it is present, or else returns v.
+func (o Optional[T]) OrElse(v T) T {
+	if o.Present {
+		return o.Value
+	}
+
+	return v
+}
+
+func (o Optional[T]) optionalValue() (reflect.Value, bool) {
+	return reflect.ValueOf(o.Value), o.Present
+}
+
+func (o *Optional[T]) optionalTarget(present bool) reflect.Value {
+	o.Present = present
+
+	return reflect.ValueOf(&o.Value).Elem()
+}
+
+type optionalEncoder interface {
+	optionalValue() (reflect.Value, bool)
+}
+
+type optionalDecoder interface {
+	optionalTarget(present bool) reflect.Value
+}
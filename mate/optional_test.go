@@ -0,0 +1,119 @@
+package mate_test
+
+import (
+	"testing"
+
+	"github.com/rbee3u/gohelp/mate"
+)
+
+type tlsConf struct {
+	Cert string
+	Key  string
+}
+
+type optionalConf struct {
+	TLS     *tlsConf
+	Forced  *tlsConf `env:",alloc"`
+	Timeout mate.Optional[int]
+	Name    mate.Optional[string]
+}
+
+func TestOptional(t *testing.T) {
+	app := mate.NewApp("MYAPP_")
+
+	var conf optionalConf
+	if err := app.Unmarshal([]string{"MYAPP_NAME="}, &conf); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+
+	if conf.TLS != nil {
+		t.Errorf("expect conf.TLS to be nil")
+	}
+
+	if conf.Forced == nil {
+		t.Errorf("expect conf.Forced not to be nil")
+	}
+
+	if _, ok := conf.Timeout.Get(); ok {
+		t.Errorf("expect conf.Timeout not to be present")
+	}
+
+	if got, want := conf.Timeout.OrElse(3), 3; got != want {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+
+	if got, ok := conf.Name.Get(); !ok || got != "" {
+		t.Errorf("got: (%q, %v), want: (%q, %v)", got, ok, "", true)
+	}
+
+	conf = optionalConf{}
+	if err := app.Unmarshal([]string{"MYAPP_TLS_CERT=a.pem", "MYAPP_TIMEOUT=5"}, &conf); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+
+	if conf.TLS == nil || conf.TLS.Cert != "a.pem" {
+		t.Errorf("got: %+v, want: %+v", conf.TLS, &tlsConf{Cert: "a.pem"})
+	}
+
+	if got, want := conf.Timeout, mate.Some(5); got != want {
+		t.Errorf("got: %+v, want: %+v", got, want)
+	}
+
+	view, err := app.View(&conf)
+	if err != nil {
+		t.Fatalf("failed to view: %v", err)
+	}
+
+	want := "MYAPP_Forced_Cert=\nMYAPP_Forced_Key=\nMYAPP_TLS_Cert=a.pem\nMYAPP_TLS_Key=\nMYAPP_Timeout=5\n"
+	if view != want {
+		t.Errorf("got: %q, want: %q", view, want)
+	}
+}
+
+type initConf struct {
+	Level       string
+	initialized bool
+}
+
+func (c *initConf) SetDefaults() error {
+	c.Level = "info"
+
+	return nil
+}
+
+func (c *initConf) Initialize() error {
+	c.initialized = true
+
+	return nil
+}
+
+type pointerConf struct {
+	Log    *initConf
+	Forced *initConf `env:",alloc"`
+}
+
+func TestOptionalPointerInitialize(t *testing.T) {
+	app := mate.NewApp("MYAPP_")
+
+	var conf pointerConf
+	if err := app.Unmarshal(nil, &conf); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+
+	if conf.Log != nil {
+		t.Errorf("expect conf.Log to be nil")
+	}
+
+	if conf.Forced == nil || conf.Forced.Level != "info" || !conf.Forced.initialized {
+		t.Errorf("got: %+v, want: %+v", conf.Forced, &initConf{Level: "info", initialized: true})
+	}
+
+	evs, err := app.ScanDefaults(&pointerConf{})
+	if err != nil {
+		t.Fatalf("failed to scan defaults: %v", err)
+	}
+
+	if ev := evs.Get("LOG_LEVEL"); ev == nil || ev.Value != "info" {
+		t.Errorf("got: %v, want: %v", ev, "info")
+	}
+}
@@ -0,0 +1,84 @@
+package mate_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/rbee3u/gohelp/mate"
+)
+
+type profileConf struct {
+	Host  string `env:""`
+	Port  int    `env:""`
+	Debug bool   `env:""`
+}
+
+func (c *profileConf) SetDefaults() error {
+	if len(c.Host) == 0 {
+		c.Host = "localhost"
+	}
+
+	return nil
+}
+
+func (c *profileConf) SetProfileDefaults(profile string) error {
+	switch profile {
+	case "dev":
+		c.Debug = true
+		c.Port = 8080
+	case "prod":
+		c.Port = 443
+	}
+
+	return nil
+}
+
+func TestAppProfiles(t *testing.T) {
+	app := mate.NewApp("MYAPP_",
+		mate.WithProfile("prod", []string{"MYAPP_HOST=example.com"}),
+		mate.WithProfile("eu", []string{"MYAPP_HOST=eu.example.com"}),
+	)
+
+	tests := []struct {
+		environ []string
+		want    profileConf
+	}{
+		{nil, profileConf{Host: "localhost"}},
+		{[]string{"MYAPP_PROFILE=dev"}, profileConf{Host: "localhost", Port: 8080, Debug: true}},
+		{[]string{"MYAPP_PROFILE=prod"}, profileConf{Host: "example.com", Port: 443}},
+		{[]string{"MYAPP_PROFILE=prod,eu"}, profileConf{Host: "eu.example.com", Port: 443}},
+		{[]string{"MYAPP_PROFILE=prod", "MYAPP_HOST=a.com"}, profileConf{Host: "a.com", Port: 443}},
+	}
+
+	for _, tt := range tests {
+		var conf profileConf
+		if err := app.Unmarshal(tt.environ, &conf); err != nil {
+			t.Fatalf("failed to unmarshal: %v", err)
+		}
+
+		if conf != tt.want {
+			t.Errorf("environ: %q, got: %+v, want: %+v", tt.environ, conf, tt.want)
+		}
+	}
+
+	if got, want := app.ActiveProfiles([]string{"MYAPP_PROFILE= prod, ,eu"}), []string{"prod", "eu"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+
+	environ := []string{"MYAPP_PROFILE=prod"}
+
+	var conf profileConf
+	if err := app.Unmarshal(environ, &conf); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+
+	view, err := app.View(&conf, app.ActiveProfiles(environ)...)
+	if err != nil {
+		t.Fatalf("failed to view: %v", err)
+	}
+
+	want := "MYAPP_Debug=false\nMYAPP_Host=example.com\nMYAPP_PROFILE=prod\nMYAPP_Port=443\n"
+	if view != want {
+		t.Errorf("got: %q, want: %q", view, want)
+	}
+}